@@ -0,0 +1,251 @@
+// Package admin provides an http.Handler to inspect and reload the
+// configuration managed by a koanfext.KoanfWrapper.
+//
+// The Handler serves the following endpoints relative to where it is mounted:
+//
+//	GET  /config      the effective configuration with sensitive values masked
+//	GET  /provenance  the Source that supplied the value of every key
+//	GET  /status      the status of every Source
+//	POST /reload      reload all the Sources, or those named by source parameters
+//
+// The configuration is served as JSON unless the format query parameter names
+// another registered format, e.g. /config?format=yaml. Every other response is
+// JSON. To mount the Handler under a prefix use http.StripPrefix:
+//
+//	mux.Handle("/admin/config/", http.StripPrefix("/admin/config", admin.NewHandler(wrapper)))
+//
+// The Handler exposes the structure of the configuration and allows anyone who
+// can reach it to trigger reloads, it should not be exposed publicly.
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/knadh/koanf/v2"
+
+	"github.com/jkratz55/koanfext"
+	jsonparser "github.com/jkratz55/koanfext/parsers/json"
+	yamlparser "github.com/jkratz55/koanfext/parsers/yaml"
+)
+
+// format is a koanf.Parser used to serve the configuration along with the
+// Content-Type of its output.
+type format struct {
+	parser      koanf.Parser
+	contentType string
+}
+
+// Handler is an http.Handler serving the configuration, provenance and status
+// of a koanfext.KoanfWrapper, and allowing reloads to be triggered.
+type Handler struct {
+	wrapper     *koanfext.KoanfWrapper
+	dumpOptions koanfext.DumpOptions
+	formats     map[string]format
+	mux         *http.ServeMux
+}
+
+// Option customizes a Handler.
+type Option func(*Handler)
+
+// DumpOptions sets the options used to redact the configuration served by
+// /config. By default, koanfext.DumpOptions{} is used which masks the keys
+// matching koanfext.DefaultSensitiveKeys along with those marked Sensitive by
+// the Sources.
+func DumpOptions(opts koanfext.DumpOptions) Option {
+	return func(h *Handler) {
+		h.dumpOptions = opts
+	}
+}
+
+// Format registers a koanf.Parser used to serve the configuration when the
+// format query parameter is name, with the given Content-Type. The json and
+// yaml formats are registered by default and can be replaced.
+func Format(name string, p koanf.Parser, contentType string) Option {
+	return func(h *Handler) {
+		if p != nil {
+			h.formats[name] = format{parser: p, contentType: contentType}
+		}
+	}
+}
+
+// NewHandler initializes a new Handler for the given KoanfWrapper.
+func NewHandler(wrapper *koanfext.KoanfWrapper, opts ...Option) *Handler {
+	h := &Handler{
+		wrapper: wrapper,
+		formats: map[string]format{
+			"json": {parser: jsonparser.Parser(), contentType: "application/json"},
+			"yaml": {parser: yamlparser.Parser(), contentType: "application/yaml"},
+		},
+		mux: http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	h.mux.HandleFunc("GET /config", h.config)
+	h.mux.HandleFunc("GET /provenance", h.provenance)
+	h.mux.HandleFunc("GET /status", h.status)
+	h.mux.HandleFunc("POST /reload", h.reload)
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// config serves the effective configuration, masking sensitive values.
+func (h *Handler) config(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		name = "json"
+	}
+	f, ok := h.formats[name]
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unsupported format %s", name))
+		return
+	}
+
+	data, err := h.wrapper.Dump(f.parser, h.dumpOptions)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", f.contentType)
+	_, _ = w.Write(data)
+}
+
+// origin is the JSON representation of a koanfext.Origin.
+type origin struct {
+	Source string `json:"source"`
+	Index  int    `json:"index"`
+}
+
+// provenance serves the Origin of every key.
+func (h *Handler) provenance(w http.ResponseWriter, r *http.Request) {
+	origins := make(map[string]origin)
+	for key, o := range h.wrapper.Provenance() {
+		origins[key] = origin{Source: o.Source, Index: o.Index}
+	}
+	writeJSON(w, http.StatusOK, origins)
+}
+
+// sourceStatus is the JSON representation of a koanfext.SourceStatus.
+type sourceStatus struct {
+	Name        string     `json:"name"`
+	Index       int        `json:"index"`
+	Policy      string     `json:"policy"`
+	Watchable   bool       `json:"watchable"`
+	Watching    bool       `json:"watching"`
+	LastLoaded  *time.Time `json:"lastLoaded,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+	Hash        string     `json:"hash,omitempty"`
+	Revision    string     `json:"revision,omitempty"`
+}
+
+// status serves the status of every Source.
+func (h *Handler) status(w http.ResponseWriter, r *http.Request) {
+	statuses := h.wrapper.Status()
+	out := make([]sourceStatus, 0, len(statuses))
+	for _, s := range statuses {
+		status := sourceStatus{
+			Name:        s.Name,
+			Index:       s.Index,
+			Policy:      s.Policy.String(),
+			Watchable:   s.Watchable,
+			Watching:    s.Watching,
+			LastLoaded:  timeOrNil(s.LastLoaded),
+			LastErrorAt: timeOrNil(s.LastErrorAt),
+			Hash:        s.Hash,
+			Revision:    s.Revision,
+		}
+		if s.LastError != nil {
+			status.LastError = s.LastError.Error()
+		}
+		out = append(out, status)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// reloadResult is the JSON representation of a koanfext.ReloadResult. Only the
+// keys that changed are reported since the values may be sensitive.
+type reloadResult struct {
+	Changed  bool              `json:"changed"`
+	Added    []string          `json:"added"`
+	Removed  []string          `json:"removed"`
+	Modified []string          `json:"modified"`
+	Errors   map[string]string `json:"errors,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// reload reloads the Sources named by the source query parameters, or every
+// Source if there are none. A reload that fails is reported with a 500 status
+// along with the error of every failing Source.
+func (h *Handler) reload(w http.ResponseWriter, r *http.Request) {
+	names := r.URL.Query()["source"]
+
+	// Unknown Sources are rejected up front so they aren't reported as a failed
+	// reload through OnError.
+	known := make(map[string]bool)
+	for _, s := range h.wrapper.Status() {
+		known[s.Name] = true
+	}
+	for _, name := range names {
+		if !known[name] {
+			writeError(w, http.StatusNotFound, fmt.Errorf("source %s does not exist", name))
+			return
+		}
+	}
+
+	result, err := h.wrapper.Reload(r.Context(), names...)
+	out := reloadResult{
+		Changed:  result.Changed,
+		Added:    keys(result.Changes.Added),
+		Removed:  keys(result.Changes.Removed),
+		Modified: keys(result.Changes.Modified),
+	}
+	if len(result.Errors) > 0 {
+		out.Errors = make(map[string]string, len(result.Errors))
+		for name, err := range result.Errors {
+			out.Errors[name] = err.Error()
+		}
+	}
+
+	status := http.StatusOK
+	if err != nil {
+		out.Error = err.Error()
+		status = http.StatusInternalServerError
+	}
+	writeJSON(w, status, out)
+}
+
+// keys returns the sorted keys of changes.
+func keys(changes []koanfext.Change) []string {
+	out := make([]string, 0, len(changes))
+	for _, c := range changes {
+		out = append(out, c.Key)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
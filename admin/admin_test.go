@@ -0,0 +1,238 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/knadh/koanf/maps"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jkratz55/koanfext"
+)
+
+// mutableProvider is a koanf.Provider whose configuration can be replaced, or
+// made to fail.
+type mutableProvider struct {
+	mu   sync.Mutex
+	conf map[string]interface{}
+	err  error
+}
+
+func (p *mutableProvider) set(conf map[string]interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.conf = conf
+}
+
+func (p *mutableProvider) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+func (p *mutableProvider) ReadBytes() ([]byte, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p *mutableProvider) Read() (map[string]interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return nil, p.err
+	}
+	return maps.Copy(p.conf), nil
+}
+
+func newTestHandler(t *testing.T) (*Handler, *mutableProvider) {
+	t.Helper()
+	base := &mutableProvider{conf: map[string]interface{}{
+		"db": map[string]interface{}{"host": "localhost", "password": "hunter2"},
+	}}
+	override := &mutableProvider{conf: map[string]interface{}{"name": "app"}}
+
+	k, err := koanfext.NewKoanfWrapper(koanfext.Sources(
+		koanfext.Source{Name: "base", Provider: base},
+		koanfext.Source{Name: "override", Provider: override}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = k.Close(context.Background()) })
+	return NewHandler(k), override
+}
+
+func serve(h http.Handler, method, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func TestHandler_Config(t *testing.T) {
+	want := map[string]interface{}{
+		"db":   map[string]interface{}{"host": "localhost", "password": koanfext.DefaultMask},
+		"name": "app",
+	}
+	tests := map[string]struct {
+		target      string
+		contentType string
+		unmarshal   func([]byte, interface{}) error
+	}{
+		"default": {
+			target:      "/config",
+			contentType: "application/json",
+			unmarshal:   json.Unmarshal,
+		},
+		"json": {
+			target:      "/config?format=json",
+			contentType: "application/json",
+			unmarshal:   json.Unmarshal,
+		},
+		"yaml": {
+			target:      "/config?format=yaml",
+			contentType: "application/yaml",
+			unmarshal:   yaml.Unmarshal,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			h, _ := newTestHandler(t)
+			rec := serve(h, http.MethodGet, tt.target)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+				t.Fatalf("expected Content-Type %s, got %s", tt.contentType, got)
+			}
+			if strings.Contains(rec.Body.String(), "hunter2") {
+				t.Fatalf("expected the password to be masked, got %s", rec.Body)
+			}
+
+			var got map[string]interface{}
+			if err := tt.unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !equalJSON(t, got, want) {
+				t.Fatalf("expected %v, got %v", want, got)
+			}
+		})
+	}
+
+	t.Run("unknown format", func(t *testing.T) {
+		h, _ := newTestHandler(t)
+		rec := serve(h, http.MethodGet, "/config?format=xml")
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got %d: %s", rec.Code, rec.Body)
+		}
+	})
+}
+
+// equalJSON compares a and b through their JSON encoding, so maps decoded from
+// YAML compare equal to those decoded from JSON.
+func equalJSON(t *testing.T, a, b interface{}) bool {
+	t.Helper()
+	ja, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jb, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(ja) == string(jb)
+}
+
+func TestHandler_Provenance(t *testing.T) {
+	h, _ := newTestHandler(t)
+	rec := serve(h, http.MethodGet, "/provenance")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var got map[string]origin
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]origin{
+		"db.host":     {Source: "base", Index: 0},
+		"db.password": {Source: "base", Index: 0},
+		"name":        {Source: "override", Index: 1},
+	}
+	if !equalJSON(t, got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestHandler_Status(t *testing.T) {
+	h, _ := newTestHandler(t)
+	rec := serve(h, http.MethodGet, "/status")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var got []sourceStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected the status of 2 sources, got %d", len(got))
+	}
+	for i, name := range []string{"base", "override"} {
+		s := got[i]
+		if s.Name != name || s.Index != i || s.Policy != "required" {
+			t.Fatalf("expected source %s at %d, got %+v", name, i, s)
+		}
+		if s.LastLoaded == nil || s.Hash == "" || s.LastError != "" {
+			t.Fatalf("expected source %s to be loaded, got %+v", name, s)
+		}
+	}
+}
+
+func TestHandler_Reload(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		h, override := newTestHandler(t)
+		override.set(map[string]interface{}{"name": "reloaded"})
+
+		rec := serve(h, http.MethodPost, "/reload?source=override")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body)
+		}
+		var got reloadResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if !got.Changed || len(got.Modified) != 1 || got.Modified[0] != "name" || got.Error != "" {
+			t.Fatalf("expected name to be modified, got %+v", got)
+		}
+	})
+
+	t.Run("unknown source", func(t *testing.T) {
+		h, _ := newTestHandler(t)
+		rec := serve(h, http.MethodPost, "/reload?source=missing")
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("expected status 404, got %d: %s", rec.Code, rec.Body)
+		}
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		h, override := newTestHandler(t)
+		override.fail(errors.New("read failed"))
+
+		rec := serve(h, http.MethodPost, "/reload")
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("expected status 500, got %d: %s", rec.Code, rec.Body)
+		}
+		var got reloadResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Changed || got.Error == "" || !strings.Contains(got.Errors["override"], "read failed") {
+			t.Fatalf("expected the failure of override to be reported, got %+v", got)
+		}
+	})
+}
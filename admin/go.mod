@@ -0,0 +1,31 @@
+module github.com/jkratz55/koanfext/admin
+
+go 1.23.5
+
+require (
+	github.com/jkratz55/koanfext v0.1.0
+	github.com/jkratz55/koanfext/parsers/json v0.1.0
+	github.com/jkratz55/koanfext/parsers/yaml v0.1.0
+	github.com/knadh/koanf/maps v0.1.1
+	github.com/knadh/koanf/v2 v2.1.2
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/go-viper/mapstructure/v2 v2.2.1 // indirect
+	github.com/jkratz55/koanfext/parsers/env v0.1.0 // indirect
+	github.com/mitchellh/copystructure v1.2.0 // indirect
+	github.com/mitchellh/reflectwalk v1.0.2 // indirect
+)
+
+// v0.1.0 is the first tag of the modules of this repository, cut once the API
+// used by this module is merged. The root and parser modules must be tagged
+// before this module is published. The replace directives build against the
+// modules of this repository during development, they are ignored by modules
+// depending on this one.
+replace (
+	github.com/jkratz55/koanfext => ..
+	github.com/jkratz55/koanfext/parsers/env => ../parsers/env
+	github.com/jkratz55/koanfext/parsers/json => ../parsers/json
+	github.com/jkratz55/koanfext/parsers/yaml => ../parsers/yaml
+)
@@ -0,0 +1,98 @@
+package koanfext
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// binder is the type-erased view of a Binding used by KoanfWrapper during a
+// reload.
+type binder interface {
+	// affected reports whether the changes touch the path of the binding.
+	affected(changes ChangeSet, delim string) bool
+
+	// stage decodes and validates the candidate configuration without
+	// publishing it.
+	stage(candidate *Snapshot) error
+
+	// commit publishes the staged value and returns a function that notifies
+	// the binding's callback of the change.
+	commit() func()
+}
+
+// Binding keeps a value of type T decoded from a path of the configuration in
+// sync with KoanfWrapper. The value is re-decoded on every reload that changes
+// a key under the path and swapped atomically, so Load always returns a
+// complete value that is never modified afterward.
+type Binding[T any] struct {
+	path     string
+	current  atomic.Pointer[T]
+	staged   *T
+	onChange func(old, new *T)
+}
+
+// Bind decodes the configuration at path into a new T and returns a Binding
+// that keeps it up to date as the configuration is reloaded. An empty path
+// binds the entire configuration.
+//
+// If T, or *T, implements interface{ Validate() error } the decoded value is
+// validated as well. A reload where the value fails to decode or validate is
+// rejected as a whole, leaving both the Binding and KoanfWrapper with the last
+// known good configuration, and the error is reported through OnError.
+//
+// onChange is optional and, if provided, is invoked with the old and new value
+// after a reload changes the value.
+func Bind[T any](k *KoanfWrapper, path string, onChange func(old, new *T)) (*Binding[T], error) {
+	b := &Binding[T]{
+		path:     path,
+		onChange: onChange,
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if err := b.stage(k.Snapshot()); err != nil {
+		return nil, err
+	}
+	b.commit()
+
+	k.bindings = append(k.bindings, b)
+	return b, nil
+}
+
+// Load returns the current value. The returned value must not be modified.
+func (b *Binding[T]) Load() *T {
+	return b.current.Load()
+}
+
+func (b *Binding[T]) affected(changes ChangeSet, delim string) bool {
+	return !changes.filter(b.path, delim).Empty()
+}
+
+func (b *Binding[T]) stage(candidate *Snapshot) error {
+	out := new(T)
+	if err := candidate.Unmarshal(b.path, out); err != nil {
+		return fmt.Errorf("bind %T to %q: %w", out, b.path, err)
+	}
+
+	if v, ok := any(out).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("bind %T to %q: %w", out, b.path, err)
+		}
+	}
+
+	b.staged = out
+	return nil
+}
+
+func (b *Binding[T]) commit() func() {
+	old := b.current.Swap(b.staged)
+	updated := b.staged
+	b.staged = nil
+
+	return func() {
+		if b.onChange != nil {
+			b.onChange(old, updated)
+		}
+	}
+}
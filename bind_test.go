@@ -0,0 +1,155 @@
+package koanfext
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/knadh/koanf/maps"
+)
+
+// mutableProvider is a koanf.Provider whose configuration can be replaced, or
+// made to fail.
+type mutableProvider struct {
+	mu   sync.Mutex
+	conf map[string]interface{}
+	err  error
+}
+
+func (p *mutableProvider) set(conf map[string]interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.conf = conf
+}
+
+func (p *mutableProvider) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+func (p *mutableProvider) ReadBytes() ([]byte, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p *mutableProvider) Read() (map[string]interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return nil, p.err
+	}
+	return maps.Copy(p.conf), nil
+}
+
+type dbConfig struct {
+	Host string `koanf:"host"`
+	Port int    `koanf:"port"`
+}
+
+func (c *dbConfig) Validate() error {
+	if c.Port <= 0 {
+		return errors.New("port must be positive")
+	}
+	return nil
+}
+
+func TestBind_RejectsReload(t *testing.T) {
+	tests := map[string]map[string]interface{}{
+		"decode fails": {
+			"db": map[string]interface{}{"host": "db", "port": "not a port"},
+		},
+		"validate fails": {
+			"db": map[string]interface{}{"host": "db", "port": 0},
+		},
+	}
+
+	for name, conf := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := &mutableProvider{conf: map[string]interface{}{
+				"db": map[string]interface{}{"host": "localhost", "port": 5432},
+			}}
+			k, err := NewKoanfWrapper(Sources(Source{Name: "mutable", Provider: p}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer k.Close(context.Background())
+
+			b, err := Bind[dbConfig](k, "db", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			snapshot, value := k.Snapshot(), b.Load()
+
+			p.set(conf)
+			var reloadErr *ReloadError
+			if _, err := k.Reload(context.Background()); !errors.As(err, &reloadErr) {
+				t.Fatalf("expected a *ReloadError, got %v", err)
+			}
+
+			if k.Snapshot() != snapshot {
+				t.Fatal("expected the previous snapshot to be kept")
+			}
+			if b.Load() != value {
+				t.Fatal("expected the previous value to be kept")
+			}
+			if got := *b.Load(); got != (dbConfig{Host: "localhost", Port: 5432}) {
+				t.Fatalf("expected the previous value to be unchanged, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestBind_OnChange(t *testing.T) {
+	p := &mutableProvider{conf: map[string]interface{}{
+		"db":    map[string]interface{}{"host": "localhost", "port": 5432},
+		"debug": false,
+	}}
+	k, err := NewKoanfWrapper(Sources(Source{Name: "mutable", Provider: p}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close(context.Background())
+
+	type change struct{ old, new *dbConfig }
+	var changes []change
+	b, err := Bind(k, "db", func(old, new *dbConfig) {
+		changes = append(changes, change{old, new})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	initial := b.Load()
+
+	// A change outside the bound path leaves the value as is
+	p.set(map[string]interface{}{
+		"db":    map[string]interface{}{"host": "localhost", "port": 5432},
+		"debug": true,
+	})
+	if _, err := k.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected no change to be notified, got %d", len(changes))
+	}
+	if b.Load() != initial {
+		t.Fatal("expected the value to be kept when the bound path is unchanged")
+	}
+
+	p.set(map[string]interface{}{
+		"db":    map[string]interface{}{"host": "db", "port": 5432},
+		"debug": true,
+	})
+	if _, err := k.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("expected a single change to be notified, got %d", len(changes))
+	}
+	if changes[0].old != initial || changes[0].new != b.Load() {
+		t.Fatal("expected the change to carry the old and new value")
+	}
+	if got := *changes[0].new; got != (dbConfig{Host: "db", Port: 5432}) {
+		t.Fatalf("expected the new value to be decoded, got %+v", got)
+	}
+}
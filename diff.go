@@ -0,0 +1,95 @@
+package koanfext
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// ChangeListener is notified with the ChangeSet describing which keys were
+// added, removed or modified after the configuration is reloaded.
+type ChangeListener func(changes ChangeSet)
+
+// Change describes a single key whose value differs between two
+// configurations. Key is the flattened key path using the delimiter of the
+// configuration, e.g. "database.pool.size". For added keys Old is nil, and for
+// removed keys New is nil.
+type Change struct {
+	Key string
+	Old interface{}
+	New interface{}
+}
+
+// ChangeSet is the structured difference between the configuration before and
+// after a reload. Each slice is sorted by key.
+//
+// Events holds the Events that triggered the reload. It is empty when the
+// reload wasn't triggered by a watch, such as a call to Reload.
+type ChangeSet struct {
+	Added    []Change
+	Removed  []Change
+	Modified []Change
+	Events   []Event
+}
+
+// Empty returns true if the ChangeSet contains no changes. Events are not
+// considered changes.
+func (c ChangeSet) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Modified) == 0
+}
+
+// Keys returns the keys of all the added, removed and modified values sorted
+// alphabetically.
+func (c ChangeSet) Keys() []string {
+	keys := make([]string, 0, len(c.Added)+len(c.Removed)+len(c.Modified))
+	for _, changes := range [][]Change{c.Added, c.Removed, c.Modified} {
+		for _, change := range changes {
+			keys = append(keys, change.Key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// diff computes the ChangeSet between two koanf instances by comparing their
+// flattened keys. Values are compared deeply, so slices and other composite
+// leaf values are only reported as modified if their contents differ.
+func diff(old, new *koanf.Koanf) ChangeSet {
+	var changes ChangeSet
+
+	oldKeys := old.Keys()
+	newKeys := new.Keys()
+
+	// Both key lists are sorted so they can be walked together in a single pass
+	i, j := 0, 0
+	for i < len(oldKeys) || j < len(newKeys) {
+		switch {
+		case j == len(newKeys) || (i < len(oldKeys) && oldKeys[i] < newKeys[j]):
+			changes.Removed = append(changes.Removed, Change{
+				Key: oldKeys[i],
+				Old: old.Get(oldKeys[i]),
+			})
+			i++
+		case i == len(oldKeys) || newKeys[j] < oldKeys[i]:
+			changes.Added = append(changes.Added, Change{
+				Key: newKeys[j],
+				New: new.Get(newKeys[j]),
+			})
+			j++
+		default:
+			oldVal, newVal := old.Get(oldKeys[i]), new.Get(newKeys[j])
+			if !reflect.DeepEqual(oldVal, newVal) {
+				changes.Modified = append(changes.Modified, Change{
+					Key: oldKeys[i],
+					Old: oldVal,
+					New: newVal,
+				})
+			}
+			i++
+			j++
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,126 @@
+package koanfext
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/knadh/koanf/v2"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name  string
+		delim string
+		old   map[string]interface{}
+		new   map[string]interface{}
+		want  ChangeSet
+	}{
+		{
+			name: "added removed and modified",
+			old: map[string]interface{}{
+				"db":    map[string]interface{}{"host": "localhost", "port": 5432},
+				"debug": false,
+			},
+			new: map[string]interface{}{
+				"db":   map[string]interface{}{"host": "db", "user": "admin"},
+				"name": "app",
+			},
+			want: ChangeSet{
+				Added: []Change{
+					{Key: "db.user", New: "admin"},
+					{Key: "name", New: "app"},
+				},
+				Removed: []Change{
+					{Key: "db.port", Old: 5432},
+					{Key: "debug", Old: false},
+				},
+				Modified: []Change{
+					{Key: "db.host", Old: "localhost", New: "db"},
+				},
+			},
+		},
+		{
+			name: "map replaced by scalar",
+			old: map[string]interface{}{
+				"db": map[string]interface{}{"host": "localhost", "port": 5432},
+			},
+			new: map[string]interface{}{
+				"db": "postgres://db:5432",
+			},
+			want: ChangeSet{
+				Added: []Change{
+					{Key: "db", New: "postgres://db:5432"},
+				},
+				Removed: []Change{
+					{Key: "db.host", Old: "localhost"},
+					{Key: "db.port", Old: 5432},
+				},
+			},
+		},
+		{
+			name: "deep equal slices",
+			old: map[string]interface{}{
+				"hosts": []interface{}{"a", "b"},
+				"pools": []interface{}{map[string]interface{}{"size": 1}},
+			},
+			new: map[string]interface{}{
+				"hosts": []interface{}{"a", "b"},
+				"pools": []interface{}{map[string]interface{}{"size": 1}},
+			},
+			want: ChangeSet{},
+		},
+		{
+			name: "modified slice",
+			old: map[string]interface{}{
+				"hosts": []interface{}{"a", "b"},
+			},
+			new: map[string]interface{}{
+				"hosts": []interface{}{"a", "c"},
+			},
+			want: ChangeSet{
+				Modified: []Change{
+					{Key: "hosts", Old: []interface{}{"a", "b"}, New: []interface{}{"a", "c"}},
+				},
+			},
+		},
+		{
+			name:  "delimiter",
+			delim: "/",
+			old: map[string]interface{}{
+				"db": map[string]interface{}{"host": "localhost"},
+			},
+			new: map[string]interface{}{
+				"db": map[string]interface{}{"host": "db", "port": 5432},
+			},
+			want: ChangeSet{
+				Added: []Change{
+					{Key: "db/port", New: 5432},
+				},
+				Modified: []Change{
+					{Key: "db/host", Old: "localhost", New: "db"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delim := tt.delim
+			if delim == "" {
+				delim = "."
+			}
+			load := func(conf map[string]interface{}) *koanf.Koanf {
+				ko := koanf.NewWithConf(koanf.Conf{Delim: delim})
+				if err := ko.Load(layerProvider(conf), nil); err != nil {
+					t.Fatal(err)
+				}
+				return ko
+			}
+
+			got := diff(load(tt.old), load(tt.new))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
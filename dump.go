@@ -0,0 +1,139 @@
+package koanfext
+
+import (
+	"path"
+	"strings"
+
+	"github.com/knadh/koanf/maps"
+	"github.com/knadh/koanf/v2"
+)
+
+// DefaultSensitiveKeys are the patterns used by Dump to identify sensitive keys
+// when DumpOptions doesn't provide any.
+var DefaultSensitiveKeys = []string{"*password*", "*secret*", "*token*"}
+
+// DefaultMask is the value substituted for sensitive values by Dump when
+// DumpOptions doesn't provide a Mask.
+const DefaultMask = "******"
+
+// DumpOptions controls how Dump redacts the configuration.
+type DumpOptions struct {
+	// SensitiveKeys are patterns matched against the flattened key path, e.g.
+	// "database.password", to determine if a value is sensitive. Patterns use
+	// the syntax of path.Match and are matched case-insensitively, except that
+	// "*" and "?" also match the delimiter, whatever it is. Patterns spelling
+	// out nested keys use the configured delimiter, e.g. "db/password" with
+	// Delimiter("/"), while "*password*" matches at any depth.
+	//
+	// Maps nested in slices are matched too, their keys are appended to the
+	// key path of the slice, e.g. "upstreams.token" for the token of every
+	// element of the upstreams slice.
+	//
+	// If nil, DefaultSensitiveKeys is used. Use an empty, non-nil slice to only
+	// redact the keys marked sensitive by the Sources.
+	SensitiveKeys []string
+
+	// Mask is substituted for sensitive values. If empty, DefaultMask is used.
+	Mask string
+}
+
+// Dump marshals the configuration with the given koanf.Parser, masking the
+// values of sensitive keys so the output is safe to log or expose for
+// debugging. A key is sensitive if it matches one of the patterns in opts, or
+// if it matches one of the Sensitive patterns of a Source that supplies it.
+// Slices are walked so the keys of maps they contain are masked as well.
+func (s *Snapshot) Dump(p koanf.Parser, opts DumpOptions) ([]byte, error) {
+	patterns := opts.SensitiveKeys
+	if patterns == nil {
+		patterns = DefaultSensitiveKeys
+	}
+	mask := opts.Mask
+	if mask == "" {
+		mask = DefaultMask
+	}
+
+	flat := s.ko.All()
+	for key, value := range flat {
+		keyPatterns := patterns
+		if sourcePatterns := s.sensitive[key]; len(sourcePatterns) > 0 {
+			keyPatterns = append(patterns[:len(patterns):len(patterns)], sourcePatterns...)
+		}
+		flat[key] = redact(key, value, keyPatterns, mask, s.ko.Delim())
+	}
+
+	return p.Marshal(maps.Unflatten(flat, s.ko.Delim()))
+}
+
+// redact returns value, or mask if key matches one of the patterns. koanf
+// treats slices as leaf values, so maps nested in a slice are walked and their
+// keys matched against the key of the slice followed by their own. The value
+// is copied rather than modified.
+func redact(key string, value interface{}, patterns []string, mask, delim string) interface{} {
+	if matchAny(patterns, key) {
+		return mask
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for name, val := range v {
+			out[name] = redact(key+delim+name, val, patterns, mask, delim)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = redact(key, elem, patterns, mask, delim)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// Dump marshals the current Snapshot with the given koanf.Parser, masking the
+// values of sensitive keys.
+func (k *KoanfWrapper) Dump(p koanf.Parser, opts DumpOptions) ([]byte, error) {
+	return k.Snapshot().Dump(p, opts)
+}
+
+// sensitivePatterns returns, for every leaf key in conf, the Sensitive
+// patterns of the Sources whose layer contains the key. The patterns are kept
+// rather than matched up front so they also apply to maps nested in slices.
+func sensitivePatterns(conf *koanf.Koanf, sources []*source, layers []map[string]interface{}) map[string][]string {
+	sensitive := make(map[string][]string)
+	leaves := conf.All()
+	for i, layer := range layers {
+		if layer == nil || len(sources[i].Sensitive) == 0 {
+			continue
+		}
+		flat, _ := maps.Flatten(layer, nil, conf.Delim())
+		for key := range flat {
+			if _, ok := leaves[key]; ok {
+				sensitive[key] = append(sensitive[key], sources[i].Sensitive...)
+			}
+		}
+	}
+	return sensitive
+}
+
+// matchAny reports whether key matches any of the patterns, ignoring case.
+// Malformed patterns never match.
+//
+// path.Match treats "/" as a separator that wildcards can't match, which would
+// break patterns such as "*password*" when "/" is the delimiter. It is replaced
+// in both the key and the pattern so wildcards match any character, whatever
+// the delimiter.
+func matchAny(patterns []string, key string) bool {
+	key = normalizeMatch(key)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(normalizeMatch(pattern), key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeMatch(s string) string {
+	return strings.ReplaceAll(strings.ToLower(s), "/", "\x00")
+}
@@ -0,0 +1,155 @@
+package koanfext
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/knadh/koanf/maps"
+)
+
+// staticProvider is a koanf.Provider supplying a fixed configuration.
+type staticProvider map[string]interface{}
+
+func (p staticProvider) ReadBytes() ([]byte, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p staticProvider) Read() (map[string]interface{}, error) {
+	return maps.Copy(p), nil
+}
+
+// captureParser is a koanf.Parser keeping the configuration it is asked to
+// marshal.
+type captureParser struct {
+	conf map[string]interface{}
+}
+
+func (p *captureParser) Unmarshal([]byte) (map[string]interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p *captureParser) Marshal(conf map[string]interface{}) ([]byte, error) {
+	p.conf = conf
+	return nil, nil
+}
+
+func TestSnapshot_Dump(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []Option
+		opts    DumpOptions
+		want    map[string]interface{}
+	}{
+		{
+			name: "default sensitive keys",
+			options: []Option{Sources(Source{Provider: staticProvider{
+				"db":        map[string]interface{}{"host": "localhost", "Password": "hunter2"},
+				"api_token": "abc",
+			}})},
+			want: map[string]interface{}{
+				"db":        map[string]interface{}{"host": "localhost", "Password": DefaultMask},
+				"api_token": DefaultMask,
+			},
+		},
+		{
+			name: "maps nested in slices",
+			options: []Option{Sources(Source{Provider: staticProvider{
+				"upstreams": []interface{}{
+					map[string]interface{}{"url": "a", "token": "t"},
+					map[string]interface{}{"url": "b", "auth": map[string]interface{}{"secret": "s"}},
+				},
+			}})},
+			opts: DumpOptions{SensitiveKeys: []string{"upstreams.token", "*secret*"}, Mask: "x"},
+			want: map[string]interface{}{
+				"upstreams": []interface{}{
+					map[string]interface{}{"url": "a", "token": "x"},
+					map[string]interface{}{"url": "b", "auth": map[string]interface{}{"secret": "x"}},
+				},
+			},
+		},
+		{
+			name: "source sensitive with empty sensitive keys",
+			options: []Option{Sources(
+				Source{Provider: staticProvider{"db": map[string]interface{}{"host": "localhost", "password": "hunter2"}}},
+				Source{
+					Provider:  staticProvider{"flags": map[string]interface{}{"beta": true}},
+					Mount:     "remote",
+					Sensitive: []string{"remote.*"},
+				})},
+			opts: DumpOptions{SensitiveKeys: []string{}},
+			want: map[string]interface{}{
+				"db":     map[string]interface{}{"host": "localhost", "password": "hunter2"},
+				"remote": map[string]interface{}{"flags": map[string]interface{}{"beta": DefaultMask}},
+			},
+		},
+		{
+			name: "source sensitive with nil sensitive keys",
+			options: []Option{Sources(
+				Source{Provider: staticProvider{"db": map[string]interface{}{"host": "localhost", "password": "hunter2"}}},
+				Source{Provider: staticProvider{"region": "eu"}, Sensitive: []string{"region"}})},
+			want: map[string]interface{}{
+				"db":     map[string]interface{}{"host": "localhost", "password": DefaultMask},
+				"region": DefaultMask,
+			},
+		},
+		{
+			name: "non dot delimiter",
+			options: []Option{
+				Delimiter("/"),
+				Sources(Source{Provider: staticProvider{
+					"db": map[string]interface{}{
+						"user.name": "admin",
+						"password":  "hunter2",
+						"host":      "localhost",
+					},
+				}}),
+			},
+			opts: DumpOptions{SensitiveKeys: []string{"db/user.name", "*password*"}},
+			want: map[string]interface{}{
+				"db": map[string]interface{}{
+					"user.name": DefaultMask,
+					"password":  DefaultMask,
+					"host":      "localhost",
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			k, err := NewKoanfWrapper(tc.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer k.Close(context.Background())
+
+			p := &captureParser{}
+			if _, err := k.Dump(p, tc.opts); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(p.conf, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, p.conf)
+			}
+		})
+	}
+}
+
+func TestSnapshot_DumpDoesNotModify(t *testing.T) {
+	k, err := NewKoanfWrapper(Sources(Source{Provider: staticProvider{
+		"upstreams": []interface{}{map[string]interface{}{"token": "t"}},
+	}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close(context.Background())
+
+	if _, err := k.Dump(&captureParser{}, DumpOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	upstreams := k.Get("upstreams").([]interface{})
+	if got := upstreams[0].(map[string]interface{})["token"]; got != "t" {
+		t.Fatalf("expected the configuration to be left unmasked, got %v", got)
+	}
+}
@@ -0,0 +1,126 @@
+package koanfext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"runtime/debug"
+)
+
+var (
+	// ErrNotExist indicates the configuration doesn't exist in the Provider,
+	// such as a missing Redis key or document. Providers should wrap it so an
+	// Optional Source is skipped. Errors matching fs.ErrNotExist, such as those
+	// returned by os.ReadFile, are treated the same.
+	ErrNotExist = errors.New("configuration does not exist")
+
+	// ErrUnavailable indicates the Provider couldn't reach the store holding the
+	// configuration. Providers should wrap it so an Optional Source that has
+	// never been loaded is skipped. Errors implementing net.Error or matching
+	// context.DeadlineExceeded are treated the same.
+	ErrUnavailable = errors.New("configuration store unavailable")
+)
+
+// notExist reports whether err indicates the configuration of a Source doesn't
+// exist.
+func notExist(err error) bool {
+	return errors.Is(err, ErrNotExist) || errors.Is(err, fs.ErrNotExist)
+}
+
+// unreachable reports whether err indicates the store holding the configuration
+// of a Source couldn't be reached, as opposed to the configuration being
+// invalid.
+func unreachable(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, ErrUnavailable) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.As(err, &netErr)
+}
+
+// ReloadError is returned when loading the configuration fails. It identifies
+// the Source responsible for the failure so the error can be acted upon, or
+// at the very least logged meaningfully.
+//
+// Source and Index are the name and position of the Source that failed. When
+// the failure isn't attributable to a single Source, such as a Validator
+// rejecting the merged configuration, Source is empty and Index is -1.
+//
+// Tolerated is true when the Policy of the Source allowed the load to proceed
+// despite the failure, without the Source or with its last known good
+// configuration. Tolerated errors are reported through OnError but don't
+// prevent the configuration from being committed.
+type ReloadError struct {
+	Source    string
+	Index     int
+	Err       error
+	Tolerated bool
+}
+
+func (e *ReloadError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("reload configuration: %s", e.Err)
+	}
+	if e.Tolerated {
+		return fmt.Sprintf("reload configuration: source %s (tolerated): %s", e.Source, e.Err)
+	}
+	return fmt.Sprintf("reload configuration: source %s: %s", e.Source, e.Err)
+}
+
+func (e *ReloadError) Unwrap() error {
+	return e.Err
+}
+
+// WatchError is returned when watching the Provider of a Source fails to start,
+// and reported through OnError when the Provider reports an error while
+// watching. Source and Index are the name and position of the Source.
+type WatchError struct {
+	Source string
+	Index  int
+	Err    error
+}
+
+func (e *WatchError) Error() string {
+	return fmt.Sprintf("watch source %s: %s", e.Source, e.Err)
+}
+
+func (e *WatchError) Unwrap() error {
+	return e.Err
+}
+
+// PanicError is reported through OnError when KoanfWrapper recovers from a
+// panic in a listener, Validator, Provider or Parser. The panic is recovered so
+// it can't crash the goroutine watching or reloading the configuration, which
+// would silently stop hot reloading.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value interface{}
+
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+func newPanicError(value interface{}) *PanicError {
+	return &PanicError{Value: value, Stack: debug.Stack()}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("recovered from panic: %v", e.Value)
+}
+
+// Unwrap returns Value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// catch invokes fn, returning a *PanicError if it panics.
+func catch(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = newPanicError(r)
+		}
+	}()
+	return fn()
+}
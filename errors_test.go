@@ -0,0 +1,216 @@
+package koanfext
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// expectPanicError waits for a *PanicError to be reported through errs.
+func expectPanicError(t *testing.T, errs <-chan error) *PanicError {
+	t.Helper()
+	for {
+		select {
+		case err := <-errs:
+			var panicErr *PanicError
+			if errors.As(err, &panicErr) {
+				return panicErr
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("expected a *PanicError to be reported")
+			return nil
+		}
+	}
+}
+
+func TestKoanfWrapper_RecoversListenerPanic(t *testing.T) {
+	p := newFakeProvider(false)
+	errs := make(chan error, 16)
+	k, err := NewKoanfWrapper(
+		Sources(Source{Name: "fake", Provider: p}),
+		OnError(func(err error) { errs <- err }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close(context.Background())
+
+	notified := make(chan int, 4)
+	k.OnConfigChanged(func() { panic("listener failed") })
+	k.OnConfigChanged(func() { notified <- k.Int("reads") })
+
+	// The worker keeps executing reloads after a listener panicked
+	for want := 2; want <= 3; want++ {
+		p.emit()
+
+		select {
+		case got := <-notified:
+			if got != want {
+				t.Fatalf("expected reads to be %d, got %d", want, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("expected the listener after the panicking one to be notified")
+		}
+		if panicErr := expectPanicError(t, errs); panicErr.Value != "listener failed" || len(panicErr.Stack) == 0 {
+			t.Fatalf("expected the panic of the listener with its stack, got %v", panicErr)
+		}
+	}
+}
+
+// panicParser is a koanf.Parser that panics on the content "panic" and
+// otherwise returns the content as the name.
+type panicParser struct{}
+
+func (panicParser) Unmarshal(b []byte) (map[string]interface{}, error) {
+	if string(b) == "panic" {
+		panic("parser failed")
+	}
+	return map[string]interface{}{"name": string(b)}, nil
+}
+
+func (panicParser) Marshal(map[string]interface{}) ([]byte, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestKoanfWrapper_RecoversLoadPanic(t *testing.T) {
+	tests := map[string]struct {
+		content    string
+		validators []Validator
+	}{
+		"parser": {
+			content: "panic",
+		},
+		"validator": {
+			content: "invalid",
+			validators: []Validator{func(candidate *Snapshot) error {
+				if candidate.String("name") == "invalid" {
+					panic("validator failed")
+				}
+				return nil
+			}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := &scriptedProvider{}
+			p.set("initial", nil)
+			k, err := NewKoanfWrapper(
+				Sources(Source{Name: "scripted", Provider: p, Parser: panicParser{}}),
+				Validators(tt.validators...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer k.Close(context.Background())
+
+			p.set(tt.content, nil)
+			_, err = k.Reload(context.Background())
+			var panicErr *PanicError
+			if !errors.As(err, &panicErr) {
+				t.Fatalf("expected the reload to fail with a *PanicError, got %v", err)
+			}
+			if got := k.String("name"); got != "initial" {
+				t.Fatalf("expected the previous configuration to be kept, got name %q", got)
+			}
+
+			// Subsequent reloads are unaffected
+			p.set("recovered", nil)
+			if _, err := k.Reload(context.Background()); err != nil {
+				t.Fatalf("expected the reload to succeed, got %v", err)
+			}
+			if got := k.String("name"); got != "recovered" {
+				t.Fatalf("expected name to be recovered, got %q", got)
+			}
+		})
+	}
+}
+
+// panicMetrics is a Metrics panicking when a load finishes.
+type panicMetrics struct {
+	nopMetrics
+	loads atomic.Int32
+}
+
+func (m *panicMetrics) LoadFinished(uint64, time.Duration, error) {
+	m.loads.Add(1)
+	panic("metrics failed")
+}
+
+func TestKoanfWrapper_RecoversMetricsPanic(t *testing.T) {
+	p := newFakeProvider(false)
+	m := &panicMetrics{}
+	errs := make(chan error, 16)
+	k, err := NewKoanfWrapper(
+		Sources(Source{Name: "fake", Provider: p}),
+		WithMetrics(m),
+		OnError(func(err error) { errs <- err }))
+	if err != nil {
+		t.Fatalf("expected the load to succeed despite the panic, got %v", err)
+	}
+	defer k.Close(context.Background())
+
+	if panicErr := expectPanicError(t, errs); panicErr.Value != "metrics failed" {
+		t.Fatalf("expected the panic of the metrics, got %v", panicErr)
+	}
+
+	// mu was released, so subsequent loads proceed
+	if _, err := k.Reload(context.Background()); err != nil {
+		t.Fatalf("expected the reload to succeed, got %v", err)
+	}
+	if got := k.Int("reads"); got != 2 {
+		t.Fatalf("expected reads to be 2, got %d", got)
+	}
+	if loads := m.loads.Load(); loads != 2 {
+		t.Fatalf("expected the metrics to observe 2 loads, got %d", loads)
+	}
+}
+
+// readPanicMetrics is a Metrics panicking when a Source is read and when a load
+// finishes.
+type readPanicMetrics struct {
+	nopMetrics
+}
+
+func (readPanicMetrics) SourceRead(string, time.Duration, error) {
+	panic("source read")
+}
+
+func (readPanicMetrics) LoadFinished(uint64, time.Duration, error) {
+	panic("load finished")
+}
+
+func TestKoanfWrapper_ReportsMetricsPanicsInOrder(t *testing.T) {
+	p := &mutableProvider{conf: map[string]interface{}{"name": "mutable"}}
+	k, err := NewKoanfWrapper(
+		Sources(Source{Name: "mutable", Provider: p}),
+		WithMetrics(readPanicMetrics{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close(context.Background())
+
+	var reported []error
+	k.OnError(func(err error) { reported = append(reported, err) })
+
+	readErr := errors.New("read failed")
+	p.fail(readErr)
+	if _, err := k.Reload(context.Background()); !errors.Is(err, readErr) {
+		t.Fatalf("expected the reload to fail, got %v", err)
+	}
+
+	// The panics are reported before Reload returns, in the order they
+	// occurred, followed by the failure of the reload.
+	if len(reported) != 3 {
+		t.Fatalf("expected 3 errors to be reported, got %v", reported)
+	}
+	for i, want := range []string{"source read", "load finished"} {
+		var panicErr *PanicError
+		if !errors.As(reported[i], &panicErr) || panicErr.Value != want {
+			t.Fatalf("expected error %d to be the panic %q, got %v", i, want, reported[i])
+		}
+	}
+	if !errors.Is(reported[2], readErr) {
+		t.Fatalf("expected the failure of the reload to be reported last, got %v", reported[2])
+	}
+}
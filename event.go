@@ -0,0 +1,64 @@
+package koanfext
+
+import (
+	"fmt"
+)
+
+// EventKind describes what happened to the configuration held by a Provider.
+type EventKind uint8
+
+const (
+	// EventModified indicates the configuration was changed in place.
+	EventModified EventKind = iota
+
+	// EventDeleted indicates the configuration was removed from the Provider.
+	EventDeleted
+
+	// EventRecreated indicates the configuration was created, or replaced as a
+	// whole, such as a file being written by renaming a new file over it.
+	EventRecreated
+
+	// EventError indicates the Provider failed while watching. Events of this
+	// kind are accompanied by an error.
+	EventError
+
+	// EventStopped indicates the Provider stopped watching and won't report
+	// further Events, such as a subscription or stream terminated by the server.
+	// Events of this kind are accompanied by an error describing why, if known.
+	EventStopped
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventModified:
+		return "modified"
+	case EventDeleted:
+		return "deleted"
+	case EventRecreated:
+		return "recreated"
+	case EventError:
+		return "error"
+	case EventStopped:
+		return "stopped"
+	default:
+		return fmt.Sprintf("EventKind(%d)", k)
+	}
+}
+
+// Event describes a change reported by a Watchable Provider. Providers pass an
+// Event to the Watch callback, KoanfWrapper fills in the Source.
+type Event struct {
+	// Source is the name of the Source whose Provider reported the Event.
+	Source string
+
+	Kind EventKind
+
+	// Revision identifies the version of the configuration after the change
+	// when the Provider can tell, such as the resourceVersion of a ConfigMap.
+	// It is empty otherwise.
+	Revision string
+
+	// Payload is the raw event from the underlying watch mechanism, such as an
+	// fsnotify.Event or a *corev1.ConfigMap.
+	Payload interface{}
+}
@@ -0,0 +1,296 @@
+package koanfext
+
+import (
+	"time"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// The getters below read from the current Snapshot. Each call loads the latest
+// Snapshot independently, so consecutive calls may observe different
+// configurations if a reload happens between them. Use KoanfWrapper.Snapshot
+// when several values must be read consistently.
+
+// Get returns the raw, uncast value of a given key path or nil if the path does
+// not exist.
+func (k *KoanfWrapper) Get(path string) interface{} {
+	return k.Snapshot().Get(path)
+}
+
+// Exists returns true if the given key path exists.
+func (k *KoanfWrapper) Exists(path string) bool {
+	return k.Snapshot().Exists(path)
+}
+
+// Keys returns all flattened keys sorted alphabetically.
+func (k *KoanfWrapper) Keys() []string {
+	return k.Snapshot().Keys()
+}
+
+// KeyMap returns a map of flattened keys and the individual parts of each key.
+func (k *KoanfWrapper) KeyMap() koanf.KeyMap {
+	return k.Snapshot().KeyMap()
+}
+
+// MapKeys returns a sorted list of keys in the map addressed by the given path.
+func (k *KoanfWrapper) MapKeys(path string) []string {
+	return k.Snapshot().MapKeys(path)
+}
+
+// All returns a copy of all flattened key paths and their values.
+func (k *KoanfWrapper) All() map[string]interface{} {
+	return k.Snapshot().All()
+}
+
+// Raw returns a copy of the full nested configuration map.
+func (k *KoanfWrapper) Raw() map[string]interface{} {
+	return k.Snapshot().Raw()
+}
+
+// Sprint returns a key -> value representation of the configuration sorted by
+// key.
+func (k *KoanfWrapper) Sprint() string {
+	return k.Snapshot().Sprint()
+}
+
+// Cut returns a new koanf.Koanf holding a copy of the config map at the given
+// path.
+func (k *KoanfWrapper) Cut(path string) *koanf.Koanf {
+	return k.Snapshot().Cut(path)
+}
+
+// Slices returns a list of koanf.Koanf instances built from a slice of maps at
+// the given path.
+func (k *KoanfWrapper) Slices(path string) []*koanf.Koanf {
+	return k.Snapshot().Slices(path)
+}
+
+// Marshal encodes the configuration using the given koanf.Parser.
+func (k *KoanfWrapper) Marshal(p koanf.Parser) ([]byte, error) {
+	return k.Snapshot().Marshal(p)
+}
+
+// Unmarshal decodes the configuration at the given path into o.
+func (k *KoanfWrapper) Unmarshal(path string, o interface{}) error {
+	return k.Snapshot().Unmarshal(path, o)
+}
+
+// UnmarshalWithConf decodes the configuration at the given path into o using
+// the given koanf.UnmarshalConf.
+func (k *KoanfWrapper) UnmarshalWithConf(path string, o interface{}, c koanf.UnmarshalConf) error {
+	return k.Snapshot().UnmarshalWithConf(path, o, c)
+}
+
+// Delim returns the key path delimiter.
+func (k *KoanfWrapper) Delim() string {
+	return k.Snapshot().Delim()
+}
+
+// String returns the string value of a given key path or "" if the path does
+// not exist.
+func (k *KoanfWrapper) String(path string) string {
+	return k.Snapshot().String(path)
+}
+
+// MustString returns the string value of a given key path and panics if the
+// path does not exist.
+func (k *KoanfWrapper) MustString(path string) string {
+	return k.Snapshot().MustString(path)
+}
+
+// Strings returns the []string value of a given key path.
+func (k *KoanfWrapper) Strings(path string) []string {
+	return k.Snapshot().Strings(path)
+}
+
+// MustStrings returns the []string value of a given key path and panics if the
+// path does not exist.
+func (k *KoanfWrapper) MustStrings(path string) []string {
+	return k.Snapshot().MustStrings(path)
+}
+
+// StringMap returns the map[string]string value of a given key path.
+func (k *KoanfWrapper) StringMap(path string) map[string]string {
+	return k.Snapshot().StringMap(path)
+}
+
+// MustStringMap returns the map[string]string value of a given key path and
+// panics if the path does not exist.
+func (k *KoanfWrapper) MustStringMap(path string) map[string]string {
+	return k.Snapshot().MustStringMap(path)
+}
+
+// StringsMap returns the map[string][]string value of a given key path.
+func (k *KoanfWrapper) StringsMap(path string) map[string][]string {
+	return k.Snapshot().StringsMap(path)
+}
+
+// MustStringsMap returns the map[string][]string value of a given key path and
+// panics if the path does not exist.
+func (k *KoanfWrapper) MustStringsMap(path string) map[string][]string {
+	return k.Snapshot().MustStringsMap(path)
+}
+
+// Bytes returns the []byte value of a given key path.
+func (k *KoanfWrapper) Bytes(path string) []byte {
+	return k.Snapshot().Bytes(path)
+}
+
+// MustBytes returns the []byte value of a given key path and panics if the path
+// does not exist.
+func (k *KoanfWrapper) MustBytes(path string) []byte {
+	return k.Snapshot().MustBytes(path)
+}
+
+// Int returns the int value of a given key path or 0 if the path does not
+// exist.
+func (k *KoanfWrapper) Int(path string) int {
+	return k.Snapshot().Int(path)
+}
+
+// MustInt returns the int value of a given key path and panics if the path does
+// not exist.
+func (k *KoanfWrapper) MustInt(path string) int {
+	return k.Snapshot().MustInt(path)
+}
+
+// Ints returns the []int value of a given key path.
+func (k *KoanfWrapper) Ints(path string) []int {
+	return k.Snapshot().Ints(path)
+}
+
+// MustInts returns the []int value of a given key path and panics if the path
+// does not exist.
+func (k *KoanfWrapper) MustInts(path string) []int {
+	return k.Snapshot().MustInts(path)
+}
+
+// IntMap returns the map[string]int value of a given key path.
+func (k *KoanfWrapper) IntMap(path string) map[string]int {
+	return k.Snapshot().IntMap(path)
+}
+
+// MustIntMap returns the map[string]int value of a given key path and panics if
+// the path does not exist.
+func (k *KoanfWrapper) MustIntMap(path string) map[string]int {
+	return k.Snapshot().MustIntMap(path)
+}
+
+// Int64 returns the int64 value of a given key path or 0 if the path does not
+// exist.
+func (k *KoanfWrapper) Int64(path string) int64 {
+	return k.Snapshot().Int64(path)
+}
+
+// MustInt64 returns the int64 value of a given key path and panics if the path
+// does not exist.
+func (k *KoanfWrapper) MustInt64(path string) int64 {
+	return k.Snapshot().MustInt64(path)
+}
+
+// Int64s returns the []int64 value of a given key path.
+func (k *KoanfWrapper) Int64s(path string) []int64 {
+	return k.Snapshot().Int64s(path)
+}
+
+// MustInt64s returns the []int64 value of a given key path and panics if the
+// path does not exist.
+func (k *KoanfWrapper) MustInt64s(path string) []int64 {
+	return k.Snapshot().MustInt64s(path)
+}
+
+// Int64Map returns the map[string]int64 value of a given key path.
+func (k *KoanfWrapper) Int64Map(path string) map[string]int64 {
+	return k.Snapshot().Int64Map(path)
+}
+
+// MustInt64Map returns the map[string]int64 value of a given key path and
+// panics if the path does not exist.
+func (k *KoanfWrapper) MustInt64Map(path string) map[string]int64 {
+	return k.Snapshot().MustInt64Map(path)
+}
+
+// Float64 returns the float64 value of a given key path or 0 if the path does
+// not exist.
+func (k *KoanfWrapper) Float64(path string) float64 {
+	return k.Snapshot().Float64(path)
+}
+
+// MustFloat64 returns the float64 value of a given key path and panics if the
+// path does not exist.
+func (k *KoanfWrapper) MustFloat64(path string) float64 {
+	return k.Snapshot().MustFloat64(path)
+}
+
+// Float64s returns the []float64 value of a given key path.
+func (k *KoanfWrapper) Float64s(path string) []float64 {
+	return k.Snapshot().Float64s(path)
+}
+
+// MustFloat64s returns the []float64 value of a given key path and panics if
+// the path does not exist.
+func (k *KoanfWrapper) MustFloat64s(path string) []float64 {
+	return k.Snapshot().MustFloat64s(path)
+}
+
+// Float64Map returns the map[string]float64 value of a given key path.
+func (k *KoanfWrapper) Float64Map(path string) map[string]float64 {
+	return k.Snapshot().Float64Map(path)
+}
+
+// MustFloat64Map returns the map[string]float64 value of a given key path and
+// panics if the path does not exist.
+func (k *KoanfWrapper) MustFloat64Map(path string) map[string]float64 {
+	return k.Snapshot().MustFloat64Map(path)
+}
+
+// Bool returns the bool value of a given key path or false if the path does not
+// exist.
+func (k *KoanfWrapper) Bool(path string) bool {
+	return k.Snapshot().Bool(path)
+}
+
+// Bools returns the []bool value of a given key path.
+func (k *KoanfWrapper) Bools(path string) []bool {
+	return k.Snapshot().Bools(path)
+}
+
+// MustBools returns the []bool value of a given key path and panics if the path
+// does not exist.
+func (k *KoanfWrapper) MustBools(path string) []bool {
+	return k.Snapshot().MustBools(path)
+}
+
+// BoolMap returns the map[string]bool value of a given key path.
+func (k *KoanfWrapper) BoolMap(path string) map[string]bool {
+	return k.Snapshot().BoolMap(path)
+}
+
+// MustBoolMap returns the map[string]bool value of a given key path and panics
+// if the path does not exist.
+func (k *KoanfWrapper) MustBoolMap(path string) map[string]bool {
+	return k.Snapshot().MustBoolMap(path)
+}
+
+// Duration returns the time.Duration value of a given key path.
+func (k *KoanfWrapper) Duration(path string) time.Duration {
+	return k.Snapshot().Duration(path)
+}
+
+// MustDuration returns the time.Duration value of a given key path and panics
+// if the path does not exist.
+func (k *KoanfWrapper) MustDuration(path string) time.Duration {
+	return k.Snapshot().MustDuration(path)
+}
+
+// Time parses the value of a given key path as time.Time using the given
+// layout.
+func (k *KoanfWrapper) Time(path, layout string) time.Time {
+	return k.Snapshot().Time(path, layout)
+}
+
+// MustTime parses the value of a given key path as time.Time and panics if the
+// path does not exist.
+func (k *KoanfWrapper) MustTime(path, layout string) time.Time {
+	return k.Snapshot().MustTime(path, layout)
+}
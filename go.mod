@@ -2,11 +2,13 @@ module github.com/jkratz55/koanfext
 
 go 1.23.4
 
-require github.com/knadh/koanf/v2 v2.1.2
+require (
+	github.com/knadh/koanf/maps v0.1.1
+	github.com/knadh/koanf/v2 v2.1.2
+)
 
 require (
 	github.com/go-viper/mapstructure/v2 v2.2.1 // indirect
-	github.com/knadh/koanf/maps v0.1.1 // indirect
 	github.com/mitchellh/copystructure v1.2.0 // indirect
 	github.com/mitchellh/reflectwalk v1.0.2 // indirect
 )
@@ -1,7 +1,12 @@
 package koanfext
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/knadh/koanf/v2"
 )
@@ -9,33 +14,84 @@ import (
 // Watchable is a type capable of watching for configuration changes and notifying
 // changes through a callback.
 //
+// Implementations pass an Event describing the change to the callback, the
+// Source of the Event is filled in by KoanfWrapper. If the watch terminates for
+// any reason other than the Provider being closed, an EventStopped Event should
+// be passed so Status can report it.
+//
 // Implementations of Watchable MUST be nonblocking, or it will cause KoanfWrapper
 // to either deadlock or react slowly to changes.
 type Watchable interface {
-	Watch(cb func(event interface{}, err error)) error
+	Watch(cb func(event Event, err error)) error
 }
 
-// Source represents the source of a configuration. The source contains the
-// Provider to load read the configuration, and the Parser to decode it.
-type Source struct {
-	Provider koanf.Provider
-	Parser   koanf.Parser
-}
+// Validator inspects a candidate configuration before it is committed and
+// returns an error if the configuration is invalid. Validators are used to
+// reject configuration that parses correctly but is semantically broken, such
+// as an out of range port or an empty DSN.
+type Validator func(candidate *Snapshot) error
 
 // KoanfWrapper is a wrapper around Koanf that abstracts away loading the
 // configuration and handling changes/reloads when a Watchable Provider is
 // changed. All Providers that implement the Watchable interface are watched
 // automatically, and the configuration is reloaded when a change is detected.
+// Only the Source whose Provider reported the change is read again, the other
+// Sources are merged from the configuration cached by the previous load.
+//
+// The loaded configuration is published as an immutable Snapshot which is
+// swapped atomically on reload. Reads never block and always observe one
+// complete configuration, never a partially loaded one.
+//
+// KoanfWrapper should be closed with Close once it is no longer needed to stop
+// watching the Sources and release the resources held by their Providers.
 //
 // Note by default KoanfWrapper has no sources. KoanfWrapper will in nearly
 // all cases we called by passing the Sources Option which provides the sources
 // to be loaded in the order they are provided.
 type KoanfWrapper struct {
-	*koanf.Koanf
-	sources         []Source
-	mu              sync.Mutex
-	onConfigChanged func()
-	onReloadError   func(err error)
+	current atomic.Pointer[Snapshot]
+	conf    koanf.Conf
+
+	// mu serializes loads. sources is only replaced while holding both mu and
+	// sourcesMu, so it can be read holding either of them, the latter without
+	// waiting for an in-progress load.
+	mu        sync.Mutex
+	sourcesMu sync.RWMutex
+	sources   []*source
+
+	onConfigChanged listeners[func()]
+	// onConfigChangedEvents are invoked alongside onConfigChanged with the
+	// Events that triggered the reload.
+	onConfigChangedEvents listeners[func(events []Event)]
+	onChange              listeners[ChangeListener]
+	onError               listeners[func(err error)]
+
+	validators []Validator
+	bindings   []binder
+	metrics    Metrics
+
+	// generation counts the configurations committed. It is guarded by mu.
+	generation uint64
+
+	// debounce is the quiet period to wait after a watch event before reloading.
+	// Zero disables debouncing and reloads on every event.
+	debounce      time.Duration
+	debounceMu    sync.Mutex
+	debounceTimer *time.Timer
+	pending       map[*source]bool
+	pendingEvents []Event
+
+	// Watch triggered reloads are executed by a single worker fed by queue, so
+	// Providers are never blocked by a slow reload or slow listeners.
+	queueSize      int
+	overflowPolicy OverflowPolicy
+	queue          *reloadQueue
+
+	// closeMu guards closed and registration with inflight so Close can't
+	// begin waiting while a watch callback is about to start a reload.
+	closeMu  sync.RWMutex
+	closed   bool
+	inflight sync.WaitGroup
 }
 
 // NewKoanfWrapper initializes a new KoanfWrapper instance. The behavior and
@@ -43,60 +99,512 @@ type KoanfWrapper struct {
 // types.
 func NewKoanfWrapper(opts ...Option) (*KoanfWrapper, error) {
 	wrapper := &KoanfWrapper{
-		Koanf:           koanf.New("."),
-		sources:         make([]Source, 0),
-		mu:              sync.Mutex{},
-		onReloadError:   func(err error) {},
-		onConfigChanged: func() {},
+		conf:           koanf.Conf{Delim: "."},
+		sources:        make([]*source, 0),
+		mu:             sync.Mutex{},
+		queueSize:      defaultQueueSize,
+		overflowPolicy: OverflowCoalesce,
+		metrics:        nopMetrics{},
 	}
-
 	for _, opt := range opts {
 		opt(wrapper)
 	}
+	wrapper.current.Store(newSnapshot(koanf.NewWithConf(wrapper.conf), nil, nil))
 
-	if err := wrapper.load(); err != nil {
+	names := make(map[string]bool, len(wrapper.sources))
+	for _, src := range wrapper.sources {
+		if names[src.name] {
+			return nil, fmt.Errorf("source %s already exists", src.name)
+		}
+		names[src.name] = true
+	}
+
+	result, err := wrapper.load(context.Background(), nil)
+	wrapper.reportRecovered(result.recovered)
+	if err != nil {
 		return nil, err
 	}
+	for _, err := range result.tolerated {
+		wrapper.reportError(err)
+	}
 
-	if err := wrapper.setupWatchers(); err != nil {
+	wrapper.queue = newReloadQueue(wrapper.queueSize, wrapper.overflowPolicy)
+	go wrapper.runWorker()
+
+	if err := wrapper.setupWatchers(result.sourceErrs); err != nil {
+		// Release any watches that were started before the failure
+		_ = wrapper.Close(context.Background())
 		return nil, err
 	}
 
 	return wrapper, nil
 }
 
-func (k *KoanfWrapper) load() error {
+// reloadResult is the outcome of a load that committed a new configuration.
+type reloadResult struct {
+	changes ChangeSet
+
+	// sourceErrs holds the errors of the Sources that failed to load, keyed by
+	// name, including those that didn't fail the load due to their Policy.
+	sourceErrs map[string]error
+
+	// tolerated holds the errors of the Sources whose Policy allowed the load to
+	// proceed, in the order of the Sources. They are reported through OnError.
+	tolerated []error
+
+	// notify holds the callbacks of the Bindings whose value was updated. They
+	// are deferred so they are invoked alongside the other listeners.
+	notify []func()
+
+	// recovered holds the panics recovered from Metrics, whether or not the
+	// load succeeded. They are reported through OnError once mu is released.
+	recovered []error
+}
+
+// load merges every Source into a candidate configuration and, once validated,
+// commits it as the current Snapshot. Only the Sources in refresh are read
+// from their Provider, the others are merged from the layer cached by the
+// previous load. A nil refresh reads every Source.
+//
+// The candidate is committed if and only if the returned error is nil, in
+// which case the reloadResult describes the difference from the previous
+// configuration. Otherwise, the current configuration is left untouched and
+// the error is a *ReloadError, one per failing Source joined together, or the
+// error of ctx if it is done before all the Sources are read.
+func (k *KoanfWrapper) load(ctx context.Context, refresh map[*source]bool) (reloadResult, error) {
 	k.mu.Lock()
 	defer k.mu.Unlock()
+	return k.loadLocked(ctx, refresh)
+}
+
+// loadLocked is load for callers already holding mu.
+func (k *KoanfWrapper) loadLocked(ctx context.Context, refresh map[*source]bool) (result reloadResult, err error) {
+	var recovered []error
+	start := time.Now()
+	k.measure(&recovered, k.metrics.LoadStarted)
+	defer func() {
+		k.measure(&recovered, func() { k.metrics.LoadFinished(k.generation, time.Since(start), err) })
+		result.recovered = recovered
+	}()
+
+	var (
+		conf       = koanf.NewWithConf(k.conf)
+		layers     = make([]map[string]interface{}, len(k.sources))
+		sourceErrs = make(map[string]error)
+		tolerated  []error
+		errs       []error
+	)
+	for i, src := range k.sources {
+		if err := ctx.Err(); err != nil {
+			return reloadResult{sourceErrs: sourceErrs}, err
+		}
+
+		layer := src.layer
+		if refresh == nil || refresh[src] {
+			var err error
+			readStart := time.Now()
+			layer, err = src.read(conf.Delim())
+			k.measure(&recovered, func() { k.metrics.SourceRead(src.name, time.Since(readStart), err) })
+			if err != nil {
+				readErr := &ReloadError{Source: src.name, Index: i, Err: err}
+				sourceErrs[src.name] = readErr
+				src.recordError(readErr)
+
+				// Every Source is read even if one fails so all the failing
+				// Sources are reported at once.
+				switch {
+				case src.Policy == Optional && notExist(err),
+					src.Policy == Optional && unreachable(err) && src.layer == nil:
+					readErr.Tolerated = true
+					tolerated = append(tolerated, readErr)
+					continue
+				case src.Policy == Optional && unreachable(err),
+					src.Policy == UseLastKnownGood && src.layer != nil:
+					readErr.Tolerated = true
+					tolerated = append(tolerated, readErr)
+					layer = src.layer
+				default:
+					errs = append(errs, readErr)
+					continue
+				}
+			} else {
+				src.recordLoad(layer)
+			}
+		}
+		if layer == nil {
+			continue
+		}
 
-	conf := koanf.New(".")
-	for _, source := range k.sources {
-		if err := conf.Load(source.Provider, source.Parser); err != nil {
+		if err := conf.Load(layerProvider(layer), nil, src.loadOptions(conf.Delim())...); err != nil {
+			err = &ReloadError{Source: src.name, Index: i, Err: err}
+			sourceErrs[src.name] = err
+			src.recordError(err)
+			errs = append(errs, err)
+			continue
+		}
+		layers[i] = layer
+	}
+	if len(errs) > 0 {
+		return reloadResult{sourceErrs: sourceErrs}, errors.Join(errs...)
+	}
+
+	candidate := newSnapshot(conf,
+		provenance(conf, k.sources, layers),
+		sensitivePatterns(conf, k.sources, layers))
+	changes := diff(k.current.Load().ko, conf)
+
+	// The candidate configuration only replaces the current one once every
+	// Validator and affected Binding accepts it, otherwise the last known good
+	// configuration is kept.
+	for _, validate := range k.validators {
+		if err := catch(func() error { return validate(candidate) }); err != nil {
+			return reloadResult{sourceErrs: sourceErrs}, &ReloadError{
+				Index: -1,
+				Err:   fmt.Errorf("configuration failed validation: %w", err),
+			}
+		}
+	}
+
+	var staged []binder
+	for _, b := range k.bindings {
+		if !b.affected(changes, k.conf.Delim) {
+			continue
+		}
+		if err := catch(func() error { return b.stage(candidate) }); err != nil {
+			return reloadResult{sourceErrs: sourceErrs}, &ReloadError{Index: -1, Err: err}
+		}
+		staged = append(staged, b)
+	}
+
+	k.current.Store(candidate)
+	k.generation++
+	for i, src := range k.sources {
+		src.layer = layers[i]
+	}
+
+	result = reloadResult{changes: changes, sourceErrs: sourceErrs, tolerated: tolerated}
+	for _, b := range staged {
+		result.notify = append(result.notify, b.commit())
+	}
+	return result, nil
+}
+
+// Snapshot returns the current configuration. The returned Snapshot is
+// immutable and remains unchanged by subsequent reloads, making it suitable
+// for reading several values that must be consistent with each other.
+func (k *KoanfWrapper) Snapshot() *Snapshot {
+	return k.current.Load()
+}
+
+// setupWatchers starts watching every Source. A Source whose failure to load
+// was tolerated, given by failed, is likely to fail to be watched for the same
+// reason, such as a remote store that is unreachable. Its watch error is
+// reported through OnError rather than failing NewKoanfWrapper.
+func (k *KoanfWrapper) setupWatchers(failed map[string]error) error {
+	for i, src := range k.sources {
+		var recovered []error
+		err := k.watch(src, &recovered)
+		k.reportRecovered(recovered)
+		if err != nil {
+			err = &WatchError{Source: src.name, Index: i, Err: err}
+			if failed[src.name] != nil {
+				k.reportError(err)
+				continue
+			}
 			return err
 		}
 	}
+	return nil
+}
 
-	k.Koanf = conf
+// watch starts watching the Provider of src if it is Watchable. A panic
+// recovered from Metrics is appended to recovered.
+func (k *KoanfWrapper) watch(src *source, recovered *[]error) error {
+	watchable, ok := src.Provider.(Watchable)
+	if !ok {
+		return nil
+	}
+	err := watchable.Watch(k.eventHandler(src))
+	k.measure(recovered, func() { k.metrics.WatchStarted(src.name, err) })
+	if err != nil {
+		src.recordError(err)
+		return err
+	}
+	src.setWatching(true)
 	return nil
 }
 
-func (k *KoanfWrapper) setupWatchers() error {
-	for _, source := range k.sources {
-		if watchable, ok := source.Provider.(Watchable); ok {
-			err := watchable.Watch(func(event interface{}, err error) {
-				if err != nil {
-					k.onReloadError(err)
-					return
-				}
-				if err := k.load(); err != nil {
-					k.onReloadError(err)
-				}
-				k.onConfigChanged()
-			})
-			if err != nil {
-				return err
-			}
+// eventHandler returns the callback passed to the Watchable Provider of src.
+// The callback queues a reload immediately, or when a reload debounce is
+// configured, once the burst of events has settled. Errors reported by the
+// Provider are forwarded to OnError. An EventStopped Event marks the Source as
+// no longer watched and doesn't trigger a reload.
+//
+// Errors are reported once the callback is no longer registered as in-flight,
+// so an OnError listener may call Close without waiting on itself.
+func (k *KoanfWrapper) eventHandler(src *source) func(event Event, err error) {
+	return func(event Event, err error) {
+		if err := k.handleEvent(src, event, err); err != nil {
+			k.reportError(err)
 		}
 	}
+}
+
+// handleEvent processes an event received from the Provider of src as an
+// in-flight reload, returning the error to report through OnError, if any.
+func (k *KoanfWrapper) handleEvent(src *source, event Event, err error) (reportErr error) {
+	if !k.acquire() {
+		// KoanfWrapper has been closed, events still trickling in from
+		// Providers shutting down are ignored.
+		return nil
+	}
+	defer k.inflight.Done()
+
+	// The callback runs on a goroutine owned by the Provider, a panic must
+	// not escape and stop it from watching.
+	defer func() {
+		if r := recover(); r != nil {
+			reportErr = newPanicError(r)
+		}
+	}()
+
+	// Events from a Source that has been removed, or is in the process of
+	// being added, are ignored.
+	index := k.indexOf(src)
+	if index < 0 {
+		return nil
+	}
+
+	event.Source = src.name
+	src.recordEvent(event)
+	if err != nil {
+		err = &WatchError{Source: src.name, Index: index, Err: err}
+		src.recordError(err)
+		return err
+	}
+	if event.Kind == EventStopped {
+		return nil
+	}
+
+	if k.debounce > 0 {
+		k.scheduleReload(src, event)
+		return nil
+	}
+	return k.enqueue(reloadRequest{
+		refresh: map[*source]bool{src: true},
+		events:  []Event{event},
+	})
+}
+
+// scheduleReload marks src as changed and arms the debounce timer, or pushes it
+// back if a reload is already pending, so a burst of events results in a
+// single reload of every Source that changed during the burst.
+func (k *KoanfWrapper) scheduleReload(src *source, event Event) {
+	k.debounceMu.Lock()
+	defer k.debounceMu.Unlock()
+
+	if k.pending == nil {
+		k.pending = make(map[*source]bool)
+	}
+	k.pending[src] = true
+	k.pendingEvents = append(k.pendingEvents, event)
+
+	if k.debounceTimer == nil {
+		k.debounceTimer = time.AfterFunc(k.debounce, func() {
+			if err := k.flushPending(); err != nil {
+				k.reportError(err)
+			}
+		})
+		return
+	}
+	k.debounceTimer.Reset(k.debounce)
+}
+
+// flushPending queues a reload of the Sources that changed during the debounce
+// period as an in-flight reload, returning the error to report through
+// OnError, if any.
+func (k *KoanfWrapper) flushPending() error {
+	if !k.acquire() {
+		return nil
+	}
+	defer k.inflight.Done()
+
+	k.debounceMu.Lock()
+	req := reloadRequest{refresh: k.pending, events: k.pendingEvents}
+	k.pending, k.pendingEvents = nil, nil
+	k.debounceMu.Unlock()
+
+	// An event received while this call waited on debounceMu re-armed the
+	// timer, the pending Sources it added were taken above so the second call
+	// has nothing left to reload.
+	if len(req.refresh) == 0 {
+		return nil
+	}
+	return k.enqueue(req)
+}
+
+// enqueue queues a reload for the worker. It returns ErrReloadDropped if a
+// queued reload had to be discarded to make room, which the caller reports
+// through OnError.
+func (k *KoanfWrapper) enqueue(req reloadRequest) error {
+	if dropped := k.queue.push(req); dropped {
+		return ErrReloadDropped
+	}
 	return nil
 }
+
+// runWorker executes the queued reloads one at a time until the queue is
+// closed.
+func (k *KoanfWrapper) runWorker() {
+	for {
+		req, ok := k.queue.pop()
+		if !ok {
+			return
+		}
+		k.safeReload(req)
+	}
+}
+
+// safeReload executes a queued reload, recovering from a panic so the worker
+// keeps executing subsequent reloads.
+func (k *KoanfWrapper) safeReload(req reloadRequest) {
+	defer func() {
+		if r := recover(); r != nil {
+			k.reportError(newPanicError(r))
+		}
+	}()
+	k.reload(req.refresh, req.events)
+}
+
+// reload loads the configuration, re-reading the Sources in refresh, and
+// reports the outcome. A failed reload is only reported to OnError, while
+// listeners are only notified once a new configuration has been committed.
+// The events that triggered the reload are passed along to the listeners.
+//
+// The reload is discarded if KoanfWrapper has been closed.
+func (k *KoanfWrapper) reload(refresh map[*source]bool, events []Event) {
+	result, err := k.guarded(func() (reloadResult, error) {
+		return k.load(context.Background(), refresh)
+	})
+	k.reportRecovered(result.recovered)
+	if errors.Is(err, errClosed) {
+		return
+	}
+	if err != nil {
+		k.reportError(err)
+		return
+	}
+	result.changes.Events = events
+	k.notify(result)
+}
+
+// notify invokes the listeners with the outcome of a committed load, starting
+// with reporting the failures tolerated by the Policy of a Source. Each kind
+// of listener is invoked in registration order, and a panicking listener
+// doesn't prevent the others from being invoked.
+func (k *KoanfWrapper) notify(result reloadResult) {
+	for _, err := range result.tolerated {
+		k.reportError(err)
+	}
+	for _, fn := range k.onConfigChanged.list() {
+		k.invoke(fn)
+	}
+	for _, fn := range k.onConfigChangedEvents.list() {
+		k.invoke(func() { fn(result.changes.Events) })
+	}
+	for _, notify := range result.notify {
+		k.invoke(notify)
+	}
+	if !result.changes.Empty() {
+		for _, fn := range k.onChange.list() {
+			k.invoke(func() { fn(result.changes) })
+		}
+	}
+}
+
+// acquire registers an in-flight reload and reports whether KoanfWrapper is
+// still open. When acquire returns true the caller must call inflight.Done
+// once finished.
+func (k *KoanfWrapper) acquire() bool {
+	k.closeMu.RLock()
+	defer k.closeMu.RUnlock()
+	if k.closed {
+		return false
+	}
+	k.inflight.Add(1)
+	return true
+}
+
+// errClosed is returned by guarded once KoanfWrapper has been closed.
+var errClosed = fmt.Errorf("%T is closed", (*KoanfWrapper)(nil))
+
+// guarded runs a load as an in-flight reload, or returns errClosed if
+// KoanfWrapper has been closed. Close waits for fn to return, but not for the
+// listeners the caller notifies of its result afterwards, so a listener may
+// call Close without blocking.
+func (k *KoanfWrapper) guarded(fn func() (reloadResult, error)) (reloadResult, error) {
+	if !k.acquire() {
+		return reloadResult{}, errClosed
+	}
+	defer k.inflight.Done()
+	return fn()
+}
+
+// Close stops watching all Sources and releases the resources held by their
+// Providers. Every Provider implementing io.Closer is closed, after which Close
+// waits for in-flight reloads to complete. Reloads that are queued but haven't
+// started are discarded. If ctx is done before the reloads drain Close returns
+// without waiting further.
+//
+// Close doesn't wait for the listeners of a reload that already completed, so
+// it may be called from a listener. Those listeners may still be running when
+// Close returns.
+//
+// The errors returned by the Providers and ctx are joined together. Calling
+// Close more than once is a no-op.
+func (k *KoanfWrapper) Close(ctx context.Context) error {
+	k.closeMu.Lock()
+	if k.closed {
+		k.closeMu.Unlock()
+		return nil
+	}
+	k.closed = true
+	k.closeMu.Unlock()
+
+	// A pending debounced reload is abandoned rather than waited on
+	k.debounceMu.Lock()
+	if k.debounceTimer != nil {
+		k.debounceTimer.Stop()
+	}
+	k.debounceMu.Unlock()
+
+	if k.queue != nil {
+		k.queue.close()
+	}
+
+	k.sourcesMu.RLock()
+	sources := k.sources
+	k.sourcesMu.RUnlock()
+
+	var errs []error
+	for _, src := range sources {
+		if err := src.close(); err != nil {
+			errs = append(errs, err)
+		}
+		src.setWatching(false)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		k.inflight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		errs = append(errs, ctx.Err())
+	}
+
+	return errors.Join(errs...)
+}
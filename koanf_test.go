@@ -0,0 +1,413 @@
+package koanfext
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeProvider is a Watchable koanf.Provider whose configuration changes on
+// every read. Once gated, reads after the initial one block until the gate is
+// opened or the provider is closed.
+type fakeProvider struct {
+	mu      sync.Mutex
+	cb      func(event Event, err error)
+	reads   atomic.Int32
+	gate    chan struct{}
+	reading chan struct{}
+	once    sync.Once
+}
+
+func newFakeProvider(gated bool) *fakeProvider {
+	p := &fakeProvider{reading: make(chan struct{}, 16)}
+	if gated {
+		p.gate = make(chan struct{})
+	}
+	return p
+}
+
+func (p *fakeProvider) ReadBytes() ([]byte, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p *fakeProvider) Read() (map[string]interface{}, error) {
+	n := p.reads.Add(1)
+	if n > 1 && p.gate != nil {
+		p.reading <- struct{}{}
+		<-p.gate
+	}
+	return map[string]interface{}{"reads": int(n)}, nil
+}
+
+func (p *fakeProvider) Watch(cb func(event Event, err error)) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cb = cb
+	return nil
+}
+
+// emit reports a change through the callback passed to Watch.
+func (p *fakeProvider) emit() {
+	p.mu.Lock()
+	cb := p.cb
+	p.mu.Unlock()
+	cb(Event{Kind: EventModified}, nil)
+}
+
+// fail reports an error while watching through the callback passed to Watch.
+func (p *fakeProvider) fail(err error) {
+	p.mu.Lock()
+	cb := p.cb
+	p.mu.Unlock()
+	cb(Event{Kind: EventError}, err)
+}
+
+func (p *fakeProvider) Close() error {
+	p.once.Do(func() {
+		if p.gate != nil {
+			close(p.gate)
+		}
+	})
+	return nil
+}
+
+func TestKoanfWrapper_ReloadDebounce(t *testing.T) {
+	p := newFakeProvider(false)
+	k, err := NewKoanfWrapper(
+		Sources(Source{Name: "fake", Provider: p}),
+		ReloadDebounce(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close(context.Background())
+
+	notified := make(chan []Event, 4)
+	k.OnConfigChangedEvents(func(events []Event) {
+		notified <- events
+	})
+
+	for i := 0; i < 10; i++ {
+		p.emit()
+	}
+
+	select {
+	case events := <-notified:
+		if len(events) != 10 {
+			t.Fatalf("expected the reload to carry 10 events, got %d", len(events))
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the burst of events to trigger a reload")
+	}
+
+	select {
+	case <-notified:
+		t.Fatal("expected the burst of events to trigger a single reload")
+	case <-time.After(200 * time.Millisecond):
+	}
+	if reads := p.reads.Load(); reads != 2 {
+		t.Fatalf("expected the source to be read twice, got %d", reads)
+	}
+	if got := k.Int("reads"); got != 2 {
+		t.Fatalf("expected reads to be 2, got %d", got)
+	}
+}
+
+func TestKoanfWrapper_CloseUnblocksOverflowBlock(t *testing.T) {
+	p := newFakeProvider(true)
+	k, err := NewKoanfWrapper(
+		Sources(Source{Name: "fake", Provider: p}),
+		ReloadQueue(1, OverflowBlock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first event occupies the worker, which blocks reading the source,
+	// and the second fills the queue.
+	p.emit()
+	<-p.reading
+	p.emit()
+
+	emitted := make(chan struct{})
+	go func() {
+		p.emit()
+		close(emitted)
+	}()
+
+	select {
+	case <-emitted:
+		t.Fatal("expected the provider to block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := k.Close(ctx); err != nil {
+		t.Fatalf("expected close to succeed, got %v", err)
+	}
+
+	select {
+	case <-emitted:
+	case <-time.After(time.Second):
+		t.Fatal("expected close to unblock the provider")
+	}
+}
+
+func TestKoanfWrapper_CloseFromListener(t *testing.T) {
+	tests := map[string]func(k *KoanfWrapper, p *fakeProvider){
+		"watch": func(k *KoanfWrapper, p *fakeProvider) {
+			p.emit()
+		},
+		"reload": func(k *KoanfWrapper, p *fakeProvider) {
+			go func() { _, _ = k.Reload(context.Background()) }()
+		},
+	}
+
+	for name, trigger := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := newFakeProvider(false)
+			k, err := NewKoanfWrapper(Sources(Source{Name: "fake", Provider: p}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			closed := make(chan error, 1)
+			k.OnConfigChanged(func() {
+				closed <- k.Close(context.Background())
+			})
+			trigger(k, p)
+
+			select {
+			case err := <-closed:
+				if err != nil {
+					t.Fatalf("expected close to succeed, got %v", err)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("expected close to return when called from a listener")
+			}
+		})
+	}
+}
+
+func TestKoanfWrapper_CloseFromErrorListener(t *testing.T) {
+	p := newFakeProvider(false)
+	k, err := NewKoanfWrapper(Sources(Source{Name: "fake", Provider: p}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	closed := make(chan error, 1)
+	k.OnError(func(err error) {
+		closed <- k.Close(context.Background())
+	})
+
+	failed := make(chan struct{})
+	go func() {
+		p.fail(errors.New("watch failed"))
+		close(failed)
+	}()
+
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatalf("expected close to succeed, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected close to return when called from an error listener")
+	}
+
+	select {
+	case <-failed:
+	case <-time.After(time.Second):
+		t.Fatal("expected the provider callback to return")
+	}
+}
+
+func TestKoanfWrapper_CloseStopsWatching(t *testing.T) {
+	k, err := NewKoanfWrapper(Sources(Source{Name: "fake", Provider: newFakeProvider(false)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := k.Status(); !status[0].Watching {
+		t.Fatal("expected the source to be watched")
+	}
+
+	if err := k.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if status := k.Status(); status[0].Watching {
+		t.Fatal("expected the source not to be watched once closed")
+	}
+}
+
+func TestKoanfWrapper_ConcurrentReads(t *testing.T) {
+	p := newFakeProvider(false)
+	k, err := NewKoanfWrapper(Sources(Source{Name: "fake", Provider: p}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close(context.Background())
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			last := 0
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				// Loads are serialized, so a reader never observes an older
+				// configuration than one it has already seen.
+				reads := k.Int("reads")
+				if reads < last {
+					t.Errorf("expected reads to never decrease, got %d after %d", reads, last)
+					return
+				}
+				last = reads
+				_ = k.All()
+
+				// A Snapshot is unaffected by reloads committed after it was taken.
+				snapshot := k.Snapshot()
+				first := snapshot.Get("reads")
+				_ = snapshot.Keys()
+				if second := snapshot.Get("reads"); first != second {
+					t.Errorf("expected the snapshot to be immutable, got %v then %v", first, second)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 50; i++ {
+		if i%2 == 0 {
+			p.emit()
+			continue
+		}
+		if _, err := k.Reload(context.Background()); err != nil {
+			t.Fatalf("reload: %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+// absentProvider is a Watchable koanf.Provider for a store that can't be
+// reached, neither read nor watched.
+type absentProvider struct{}
+
+func (absentProvider) ReadBytes() ([]byte, error) {
+	return nil, ErrUnavailable
+}
+
+func (absentProvider) Read() (map[string]interface{}, error) {
+	return nil, ErrUnavailable
+}
+
+func (absentProvider) Watch(func(event Event, err error)) error {
+	return ErrUnavailable
+}
+
+func TestKoanfWrapper_OptionalSourceWatchFails(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	k, err := NewKoanfWrapper(
+		Sources(
+			Source{Name: "base", Provider: newFakeProvider(false)},
+			Source{Name: "override", Provider: absentProvider{}, Policy: Optional}),
+		OnError(func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		}))
+	if err != nil {
+		t.Fatalf("expected the optional source to be skipped, got %v", err)
+	}
+	defer k.Close(context.Background())
+
+	if got := k.Int("reads"); got != 1 {
+		t.Fatalf("expected reads to be 1, got %d", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 2 {
+		t.Fatalf("expected the read and watch errors to be reported, got %v", errs)
+	}
+	var reloadErr *ReloadError
+	if !errors.As(errs[0], &reloadErr) || !reloadErr.Tolerated || reloadErr.Source != "override" {
+		t.Fatalf("expected a tolerated *ReloadError for override, got %v", errs[0])
+	}
+	var watchErr *WatchError
+	if !errors.As(errs[1], &watchErr) || watchErr.Source != "override" || !errors.Is(watchErr, ErrUnavailable) {
+		t.Fatalf("expected a *WatchError for override, got %v", errs[1])
+	}
+}
+
+// failingWatch is a fakeProvider that can be read but fails to be watched.
+type failingWatch struct {
+	*fakeProvider
+}
+
+func (failingWatch) Watch(func(event Event, err error)) error {
+	return errors.New("watch failed")
+}
+
+func TestKoanfWrapper_RequiredSourceWatchFails(t *testing.T) {
+	_, err := NewKoanfWrapper(Sources(Source{Name: "fake", Provider: failingWatch{newFakeProvider(false)}}))
+	var watchErr *WatchError
+	if !errors.As(err, &watchErr) || watchErr.Source != "fake" {
+		t.Fatalf("expected a *WatchError for fake, got %v", err)
+	}
+}
+
+func TestKoanfWrapper_OptionalSourceReload(t *testing.T) {
+	p := &mutableProvider{conf: map[string]interface{}{"feature": "on"}}
+	k, err := NewKoanfWrapper(Sources(
+		Source{Name: "base", Provider: staticProvider{"feature": "off"}},
+		Source{Name: "override", Provider: p, Policy: Optional}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close(context.Background())
+
+	changes := 0
+	k.OnChange(func(ChangeSet) { changes++ })
+
+	// An unreachable store keeps the configuration previously loaded from it
+	p.fail(context.DeadlineExceeded)
+	result, err := k.Reload(context.Background())
+	if err != nil {
+		t.Fatalf("expected the failure to be tolerated, got %v", err)
+	}
+	if !errors.Is(result.Errors["override"], context.DeadlineExceeded) {
+		t.Fatalf("expected the tolerated error to be reported, got %v", result.Errors)
+	}
+	if got := k.String("feature"); got != "on" {
+		t.Fatalf("expected the override to be kept, got feature %q", got)
+	}
+	if changes != 0 {
+		t.Fatalf("expected no change to be notified, got %d", changes)
+	}
+
+	// A Source that no longer exists is skipped
+	p.fail(ErrNotExist)
+	if _, err := k.Reload(context.Background()); err != nil {
+		t.Fatalf("expected the failure to be tolerated, got %v", err)
+	}
+	if got := k.String("feature"); got != "off" {
+		t.Fatalf("expected the override to be skipped, got feature %q", got)
+	}
+	if changes != 1 {
+		t.Fatalf("expected the change to be notified, got %d", changes)
+	}
+}
@@ -0,0 +1,113 @@
+package koanfext
+
+import (
+	"sync"
+)
+
+// listeners is a list of listener functions of type F invoked in the order
+// they were registered. The list is copy-on-write, so it is iterated without
+// holding the lock and listeners may register or cancel listeners themselves.
+type listeners[F any] struct {
+	mu      sync.RWMutex
+	entries []*listener[F]
+}
+
+// listener wraps a function so it can be identified when it is cancelled,
+// since functions aren't comparable.
+type listener[F any] struct {
+	fn F
+}
+
+// add registers fn and returns a function that removes it. The returned
+// function is safe to call more than once.
+func (l *listeners[F]) add(fn F) (cancel func()) {
+	entry := &listener[F]{fn: fn}
+
+	l.mu.Lock()
+	l.entries = append(l.entries, entry)
+	l.mu.Unlock()
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for i, e := range l.entries {
+			if e == entry {
+				l.entries = append(l.entries[:i:i], l.entries[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// list returns the registered functions in registration order.
+func (l *listeners[F]) list() []F {
+	l.mu.RLock()
+	entries := l.entries
+	l.mu.RUnlock()
+
+	fns := make([]F, 0, len(entries))
+	for _, e := range entries {
+		fns = append(fns, e.fn)
+	}
+	return fns
+}
+
+// OnConfigChanged registers a function that is invoked each time a reload
+// successfully commits a new configuration, after the functions registered
+// before it. It returns a function that removes the listener.
+func (k *KoanfWrapper) OnConfigChanged(fn func()) (cancel func()) {
+	if fn == nil {
+		panic("fn cannot be nil")
+	}
+	return k.onConfigChanged.add(fn)
+}
+
+// OnConfigChangedEvents registers a function that is invoked alongside the
+// OnConfigChanged listeners with the Events that triggered the reload. It
+// returns a function that removes the listener.
+func (k *KoanfWrapper) OnConfigChangedEvents(fn func(events []Event)) (cancel func()) {
+	if fn == nil {
+		panic("fn cannot be nil")
+	}
+	return k.onConfigChangedEvents.add(fn)
+}
+
+// OnChange registers a ChangeListener that is invoked after a reload that
+// changed the configuration. It returns a function that removes the listener.
+func (k *KoanfWrapper) OnChange(fn ChangeListener) (cancel func()) {
+	if fn == nil {
+		panic("fn cannot be nil")
+	}
+	return k.onChange.add(fn)
+}
+
+// OnError registers a function that is invoked when a Provider reports an
+// error while watching, or when a reload fails. It returns a function that
+// removes the listener.
+func (k *KoanfWrapper) OnError(fn func(err error)) (cancel func()) {
+	if fn == nil {
+		panic("fn cannot be nil")
+	}
+	return k.onError.add(fn)
+}
+
+// invoke calls a listener, recovering from a panic so one listener can't stop
+// the others from being notified or crash the goroutine notifying them. The
+// panic is reported to the OnError listeners as a *PanicError.
+func (k *KoanfWrapper) invoke(fn func()) {
+	if err := catch(func() error { fn(); return nil }); err != nil {
+		k.reportError(err)
+	}
+}
+
+// reportError invokes every OnError listener with err. A panic in an OnError
+// listener is recovered and discarded since reporting it would risk an endless
+// cycle.
+func (k *KoanfWrapper) reportError(err error) {
+	for _, fn := range k.onError.list() {
+		func() {
+			defer func() { _ = recover() }()
+			fn(err)
+		}()
+	}
+}
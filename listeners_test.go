@@ -0,0 +1,107 @@
+package koanfext
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestKoanfWrapper_ListenerCancel(t *testing.T) {
+	p := newFakeProvider(false)
+	k, err := NewKoanfWrapper(Sources(Source{Name: "fake", Provider: p}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close(context.Background())
+
+	var calls []string
+	cancelFirst := k.OnConfigChanged(func() { calls = append(calls, "first") })
+	cancelChange := k.OnChange(func(ChangeSet) { calls = append(calls, "change") })
+	k.OnConfigChanged(func() { calls = append(calls, "second") })
+
+	if _, err := k.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"first", "second", "change"}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("expected listeners to be invoked in registration order %v, got %v", want, calls)
+	}
+
+	// Cancelling twice is harmless and leaves the other listeners registered
+	cancelFirst()
+	cancelFirst()
+	cancelChange()
+	cancelChange()
+
+	calls = nil
+	if _, err := k.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"second"}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("expected only the remaining listener to be invoked, got %v", calls)
+	}
+}
+
+func TestKoanfWrapper_ErrorListenerCancel(t *testing.T) {
+	p := &mutableProvider{conf: map[string]interface{}{"name": "mutable"}}
+	k, err := NewKoanfWrapper(Sources(Source{Name: "mutable", Provider: p}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close(context.Background())
+
+	reported := 0
+	cancel := k.OnError(func(error) { reported++ })
+	p.fail(errors.New("read failed"))
+
+	if _, err := k.Reload(context.Background()); err == nil {
+		t.Fatal("expected the reload to fail")
+	}
+	cancel()
+	cancel()
+	if _, err := k.Reload(context.Background()); err == nil {
+		t.Fatal("expected the reload to fail")
+	}
+	if reported != 1 {
+		t.Fatalf("expected the error listener to be invoked once, got %d", reported)
+	}
+}
+
+func TestKoanfWrapper_ListenerRegistersDuringNotify(t *testing.T) {
+	p := newFakeProvider(false)
+	k, err := NewKoanfWrapper(Sources(Source{Name: "fake", Provider: p}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close(context.Background())
+
+	var calls []string
+	var cancelVictim func()
+	registered := false
+	k.OnConfigChanged(func() {
+		calls = append(calls, "registrar")
+		if !registered {
+			registered = true
+			k.OnConfigChanged(func() { calls = append(calls, "added") })
+			cancelVictim()
+		}
+	})
+	cancelVictim = k.OnConfigChanged(func() { calls = append(calls, "victim") })
+
+	// Listeners registered or cancelled during a notification take effect from
+	// the next one.
+	if _, err := k.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"registrar", "victim"}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("expected %v, got %v", want, calls)
+	}
+
+	calls = nil
+	if _, err := k.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"registrar", "added"}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("expected %v, got %v", want, calls)
+	}
+}
@@ -0,0 +1,169 @@
+package koanfext
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// MergeStrategy determines how the configuration of a Source is merged into
+// the configuration of the Sources before it.
+type MergeStrategy uint8
+
+const (
+	// MergeDeep recursively merges maps, while any other value, including
+	// slices, replaces the existing value. This is the default MergeStrategy
+	// and matches the behavior of koanf.
+	MergeDeep MergeStrategy = iota
+
+	// MergeReplace replaces the existing value of every top-level key the Source
+	// defines wholesale, maps included. When the Source has a Mount the keys
+	// directly under the Mount path are replaced, so the siblings of the Mount
+	// path supplied by other Sources are kept.
+	MergeReplace
+
+	// MergeAppend recursively merges maps like MergeDeep, but appends slices to
+	// the existing slices instead of replacing them.
+	MergeAppend
+
+	// MergeUnion recursively merges maps like MergeDeep, but merges slices as a
+	// union. Elements that are maps and have a value for the MergeKey of the
+	// Source replace the existing element with the same value, while other
+	// elements are appended unless an equal element already exists.
+	MergeUnion
+)
+
+func (m MergeStrategy) String() string {
+	switch m {
+	case MergeDeep:
+		return "deep"
+	case MergeReplace:
+		return "replace"
+	case MergeAppend:
+		return "append"
+	case MergeUnion:
+		return "union"
+	default:
+		return fmt.Sprintf("MergeStrategy(%d)", m)
+	}
+}
+
+// loadOptions returns the koanf.Option used to merge the Source according to
+// its MergeStrategy. MergeDeep uses the koanf default merge, so it honors
+// koanf.Conf.StrictMerge.
+func (s *source) loadOptions(delim string) []koanf.Option {
+	switch s.Merge {
+	case MergeReplace:
+		var mountPath []string
+		if s.Mount != "" {
+			mountPath = strings.Split(s.Mount, delim)
+		}
+		return []koanf.Option{koanf.WithMergeFunc(func(src, dest map[string]interface{}) error {
+			mergeReplace(src, dest, mountPath)
+			return nil
+		})}
+	case MergeAppend, MergeUnion:
+		return []koanf.Option{koanf.WithMergeFunc(func(src, dest map[string]interface{}) error {
+			mergeSlices(src, dest, s.Merge, s.MergeKey)
+			return nil
+		})}
+	default:
+		return nil
+	}
+}
+
+// mergeReplace replaces the values in dest of the keys src defines under the
+// mount path, creating the maps along the path in dest as needed. src is the
+// layer of a Source, already nested under the mount path.
+func mergeReplace(src, dest map[string]interface{}, mountPath []string) {
+	for _, key := range mountPath {
+		next, ok := dest[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			dest[key] = next
+		}
+		src, _ = src[key].(map[string]interface{})
+		dest = next
+	}
+
+	for key, val := range src {
+		dest[key] = val
+	}
+}
+
+// mergeSlices merges src into dest recursively, combining slices according to
+// strategy.
+func mergeSlices(src, dest map[string]interface{}, strategy MergeStrategy, mergeKey string) {
+	for key, val := range src {
+		existing, ok := dest[key]
+		if !ok {
+			dest[key] = val
+			continue
+		}
+
+		switch v := val.(type) {
+		case map[string]interface{}:
+			if existingMap, ok := existing.(map[string]interface{}); ok {
+				mergeSlices(v, existingMap, strategy, mergeKey)
+				continue
+			}
+		case []interface{}:
+			if existingSlice, ok := existing.([]interface{}); ok {
+				if strategy == MergeAppend {
+					dest[key] = append(existingSlice[:len(existingSlice):len(existingSlice)], v...)
+				} else {
+					dest[key] = union(existingSlice, v, mergeKey)
+				}
+				continue
+			}
+		}
+		dest[key] = val
+	}
+}
+
+// union returns the elements of dest followed by the elements of src not
+// already present in dest. Elements that are maps with a value for mergeKey
+// replace the element in dest with the same value.
+func union(dest, src []interface{}, mergeKey string) []interface{} {
+	out := make([]interface{}, len(dest), len(dest)+len(src))
+	copy(out, dest)
+
+	for _, elem := range src {
+		index := -1
+		if m, ok := elem.(map[string]interface{}); ok && mergeKey != "" {
+			if id, ok := m[mergeKey]; ok {
+				index = indexOfKey(out, mergeKey, id)
+			}
+		}
+		if index < 0 {
+			index = indexOf(out, elem)
+		}
+
+		if index < 0 {
+			out = append(out, elem)
+		} else {
+			out[index] = elem
+		}
+	}
+	return out
+}
+
+func indexOfKey(elems []interface{}, mergeKey string, id interface{}) int {
+	for i, elem := range elems {
+		if m, ok := elem.(map[string]interface{}); ok && reflect.DeepEqual(m[mergeKey], id) {
+			return i
+		}
+	}
+	return -1
+}
+
+func indexOf(elems []interface{}, target interface{}) int {
+	for i, elem := range elems {
+		if reflect.DeepEqual(elem, target) {
+			return i
+		}
+	}
+	return -1
+}
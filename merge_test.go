@@ -0,0 +1,151 @@
+package koanfext
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestMergeStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     staticProvider
+		override Source
+		want     map[string]interface{}
+	}{
+		{
+			name: "deep",
+			base: staticProvider{
+				"db":    map[string]interface{}{"host": "localhost", "port": 5432},
+				"hosts": []interface{}{"a", "b"},
+			},
+			override: Source{Provider: staticProvider{
+				"db":    map[string]interface{}{"host": "db"},
+				"hosts": []interface{}{"c"},
+			}},
+			want: map[string]interface{}{
+				"db":    map[string]interface{}{"host": "db", "port": 5432},
+				"hosts": []interface{}{"c"},
+			},
+		},
+		{
+			name: "replace",
+			base: staticProvider{
+				"db":    map[string]interface{}{"host": "localhost", "port": 5432},
+				"debug": true,
+			},
+			override: Source{
+				Provider: staticProvider{"db": map[string]interface{}{"host": "db"}},
+				Merge:    MergeReplace,
+			},
+			want: map[string]interface{}{
+				"db":    map[string]interface{}{"host": "db"},
+				"debug": true,
+			},
+		},
+		{
+			name: "replace under mount",
+			base: staticProvider{
+				"app": map[string]interface{}{
+					"features": map[string]interface{}{
+						"beta":   map[string]interface{}{"enabled": true, "rollout": 10},
+						"legacy": true,
+					},
+					"name": "app",
+				},
+			},
+			override: Source{
+				Provider: staticProvider{"beta": map[string]interface{}{"enabled": false}},
+				Mount:    "app.features",
+				Merge:    MergeReplace,
+			},
+			want: map[string]interface{}{
+				"app": map[string]interface{}{
+					"features": map[string]interface{}{
+						"beta":   map[string]interface{}{"enabled": false},
+						"legacy": true,
+					},
+					"name": "app",
+				},
+			},
+		},
+		{
+			name: "replace under missing mount",
+			base: staticProvider{"debug": true},
+			override: Source{
+				Provider: staticProvider{"beta": true},
+				Mount:    "app.features",
+				Merge:    MergeReplace,
+			},
+			want: map[string]interface{}{
+				"app":   map[string]interface{}{"features": map[string]interface{}{"beta": true}},
+				"debug": true,
+			},
+		},
+		{
+			name: "append",
+			base: staticProvider{
+				"hosts": []interface{}{"a", "b"},
+				"db":    map[string]interface{}{"replicas": []interface{}{"r1"}, "port": 5432},
+			},
+			override: Source{
+				Provider: staticProvider{
+					"hosts": []interface{}{"b", "c"},
+					"db":    map[string]interface{}{"replicas": []interface{}{"r2"}},
+				},
+				Merge: MergeAppend,
+			},
+			want: map[string]interface{}{
+				"hosts": []interface{}{"a", "b", "b", "c"},
+				"db":    map[string]interface{}{"replicas": []interface{}{"r1", "r2"}, "port": 5432},
+			},
+		},
+		{
+			name: "union",
+			base: staticProvider{"hosts": []interface{}{"a", "b"}},
+			override: Source{
+				Provider: staticProvider{"hosts": []interface{}{"b", "c"}},
+				Merge:    MergeUnion,
+			},
+			want: map[string]interface{}{
+				"hosts": []interface{}{"a", "b", "c"},
+			},
+		},
+		{
+			name: "union by merge key",
+			base: staticProvider{"servers": []interface{}{
+				map[string]interface{}{"name": "a", "port": 1},
+				map[string]interface{}{"name": "b", "port": 2},
+			}},
+			override: Source{
+				Provider: staticProvider{"servers": []interface{}{
+					map[string]interface{}{"name": "b", "port": 3},
+					map[string]interface{}{"name": "c", "port": 4},
+				}},
+				Merge:    MergeUnion,
+				MergeKey: "name",
+			},
+			want: map[string]interface{}{
+				"servers": []interface{}{
+					map[string]interface{}{"name": "a", "port": 1},
+					map[string]interface{}{"name": "b", "port": 3},
+					map[string]interface{}{"name": "c", "port": 4},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			k, err := NewKoanfWrapper(Sources(Source{Provider: tc.base}, tc.override))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer k.Close(context.Background())
+
+			if got := k.Raw(); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package koanfext
+
+import (
+	"time"
+)
+
+// Metrics receives measurements of the loads and watches performed by
+// KoanfWrapper, for instance to export them to a monitoring system.
+//
+// Implementations must be safe for concurrent use and should return quickly
+// since the load methods are invoked while the configuration is being loaded.
+// A panic in an implementation is recovered and reported through OnError as a
+// *PanicError without affecting the load.
+type Metrics interface {
+	// LoadStarted is invoked when a load begins, whether it is the initial load,
+	// a watch triggered reload, or caused by Reload, AddSource or RemoveSource.
+	LoadStarted()
+
+	// SourceRead is invoked after the named Source is read from its Provider
+	// with the duration of the read and its error, nil if it succeeded. Sources
+	// merged from the configuration cached by the previous load aren't read.
+	SourceRead(source string, duration time.Duration, err error)
+
+	// LoadFinished is invoked when a load ends with the generation of the
+	// current configuration, the duration of the load and its error, nil if a
+	// new configuration was committed. The generation starts at 1 with the
+	// initial load and is incremented every time a configuration is committed.
+	LoadFinished(generation uint64, duration time.Duration, err error)
+
+	// WatchStarted is invoked for every Source with a Watchable Provider when
+	// its watch is started, with the error returned by Watch if any.
+	WatchStarted(source string, err error)
+}
+
+// nopMetrics is the Metrics used when none are configured.
+type nopMetrics struct{}
+
+func (nopMetrics) LoadStarted()                              {}
+func (nopMetrics) SourceRead(string, time.Duration, error)   {}
+func (nopMetrics) LoadFinished(uint64, time.Duration, error) {}
+func (nopMetrics) WatchStarted(string, error)                {}
+
+// measure invokes a Metrics method, recovering from a panic so a faulty Metrics
+// implementation can neither fail a load nor leave mu locked. Since mu may be
+// held, the panic is appended to recovered rather than reported, leaving the
+// caller to report it through OnError once mu is released.
+func (k *KoanfWrapper) measure(recovered *[]error, fn func()) {
+	if err := catch(func() error { fn(); return nil }); err != nil {
+		*recovered = append(*recovered, err)
+	}
+}
+
+// reportRecovered reports the panics recovered by measure, in the order they
+// occurred.
+func (k *KoanfWrapper) reportRecovered(recovered []error) {
+	for _, err := range recovered {
+		k.reportError(err)
+	}
+}
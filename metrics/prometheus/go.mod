@@ -0,0 +1,31 @@
+module github.com/jkratz55/koanfext/metrics/prometheus
+
+go 1.23.5
+
+require (
+	github.com/jkratz55/koanfext v0.1.0
+	github.com/knadh/koanf/maps v0.1.1
+	github.com/prometheus/client_golang v1.20.5
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/go-viper/mapstructure/v2 v2.2.1 // indirect
+	github.com/knadh/koanf/v2 v2.1.2 // indirect
+	github.com/kylelemons/godebug v1.1.0 // indirect
+	github.com/mitchellh/copystructure v1.2.0 // indirect
+	github.com/mitchellh/reflectwalk v1.0.2 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/client_model v0.6.1 // indirect
+	github.com/prometheus/common v0.55.0 // indirect
+	github.com/prometheus/procfs v0.15.1 // indirect
+	golang.org/x/sys v0.22.0 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+)
+
+// v0.1.0 is the first tag of the root module, cut once the Metrics API used by
+// this module is merged. It must be pushed before this module is published.
+// The replace directive builds against the root module of this repository
+// during development, it is ignored by modules depending on this one.
+replace github.com/jkratz55/koanfext => ../..
@@ -0,0 +1,213 @@
+// Package prometheus implements koanfext.Metrics as a prometheus.Collector so
+// the loads and watches of a koanfext.KoanfWrapper can be exported to
+// Prometheus.
+//
+//	collector := prometheus.NewCollector()
+//	registry.MustRegister(collector)
+//	wrapper, err := koanfext.NewKoanfWrapper(
+//		koanfext.Sources(sources...),
+//		koanfext.WithMetrics(collector))
+//
+// The following metrics are exported, prefixed by the namespace which is
+// koanfext by default:
+//
+//	source_load_attempts_total                 counter   source
+//	source_load_successes_total                counter   source
+//	source_load_failures_total                 counter   source
+//	source_load_duration_seconds               histogram source
+//	source_last_success_timestamp_seconds      gauge     source
+//	source_watch_failures_total                counter   source
+//	reloads_total                              counter   result
+//	reload_duration_seconds                    histogram
+//	config_last_success_timestamp_seconds      gauge
+//	config_generation                          gauge
+package prometheus
+
+import (
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/jkratz55/koanfext"
+)
+
+var (
+	_ koanfext.Metrics = (*Collector)(nil)
+	_ prom.Collector   = (*Collector)(nil)
+)
+
+// Collector is a prometheus.Collector recording the measurements reported by
+// KoanfWrapper through the koanfext.Metrics interface.
+type Collector struct {
+	sourceAttempts    *prom.CounterVec
+	sourceSuccesses   *prom.CounterVec
+	sourceFailures    *prom.CounterVec
+	sourceDuration    *prom.HistogramVec
+	sourceLastSuccess *prom.GaugeVec
+	watchFailures     *prom.CounterVec
+	reloads           *prom.CounterVec
+	reloadDuration    prom.Histogram
+	lastSuccess       prom.Gauge
+	generation        prom.Gauge
+}
+
+// config holds the settings applied by Option.
+type config struct {
+	namespace   string
+	constLabels prom.Labels
+	buckets     []float64
+}
+
+// Option customizes a Collector.
+type Option func(*config)
+
+// Namespace sets the namespace prefixed to the name of every metric,
+// "koanfext" by default.
+func Namespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// ConstLabels sets labels added to every metric, which is useful to tell apart
+// several KoanfWrapper instances registered with the same registry.
+func ConstLabels(labels prom.Labels) Option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
+
+// Buckets sets the buckets of the duration histograms, prometheus.DefBuckets
+// by default.
+func Buckets(buckets []float64) Option {
+	return func(c *config) {
+		if len(buckets) > 0 {
+			c.buckets = buckets
+		}
+	}
+}
+
+// NewCollector initializes a new Collector. The Collector must be registered
+// with a prometheus.Registerer and passed to KoanfWrapper with the
+// koanfext.WithMetrics Option.
+func NewCollector(opts ...Option) *Collector {
+	c := &config{
+		namespace: "koanfext",
+		buckets:   prom.DefBuckets,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	counter := func(name, help string, labels ...string) *prom.CounterVec {
+		return prom.NewCounterVec(prom.CounterOpts{
+			Namespace:   c.namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: c.constLabels,
+		}, labels)
+	}
+	gauge := func(name, help string) prom.GaugeOpts {
+		return prom.GaugeOpts{
+			Namespace:   c.namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: c.constLabels,
+		}
+	}
+	histogram := func(name, help string) prom.HistogramOpts {
+		return prom.HistogramOpts{
+			Namespace:   c.namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: c.constLabels,
+			Buckets:     c.buckets,
+		}
+	}
+
+	return &Collector{
+		sourceAttempts: counter("source_load_attempts_total",
+			"Number of times a source was read.", "source"),
+		sourceSuccesses: counter("source_load_successes_total",
+			"Number of times a source was read successfully.", "source"),
+		sourceFailures: counter("source_load_failures_total",
+			"Number of times reading a source failed.", "source"),
+		sourceDuration: prom.NewHistogramVec(histogram("source_load_duration_seconds",
+			"Duration of reading a source."), []string{"source"}),
+		sourceLastSuccess: prom.NewGaugeVec(gauge("source_last_success_timestamp_seconds",
+			"Unix time a source was last read successfully."), []string{"source"}),
+		watchFailures: counter("source_watch_failures_total",
+			"Number of times watching a source failed to start.", "source"),
+		reloads: counter("reloads_total",
+			"Number of loads of the configuration by result, success or failure.", "result"),
+		reloadDuration: prom.NewHistogram(histogram("reload_duration_seconds",
+			"Duration of loading the configuration.")),
+		lastSuccess: prom.NewGauge(gauge("config_last_success_timestamp_seconds",
+			"Unix time a configuration was last committed.")),
+		generation: prom.NewGauge(gauge("config_generation",
+			"Number of configurations committed.")),
+	}
+}
+
+// LoadStarted implements koanfext.Metrics. Loads are counted once they finish.
+func (c *Collector) LoadStarted() {}
+
+// SourceRead implements koanfext.Metrics.
+func (c *Collector) SourceRead(source string, duration time.Duration, err error) {
+	c.sourceAttempts.WithLabelValues(source).Inc()
+	c.sourceDuration.WithLabelValues(source).Observe(duration.Seconds())
+	if err != nil {
+		c.sourceFailures.WithLabelValues(source).Inc()
+		return
+	}
+	c.sourceSuccesses.WithLabelValues(source).Inc()
+	c.sourceLastSuccess.WithLabelValues(source).SetToCurrentTime()
+}
+
+// LoadFinished implements koanfext.Metrics.
+func (c *Collector) LoadFinished(generation uint64, duration time.Duration, err error) {
+	c.reloadDuration.Observe(duration.Seconds())
+	c.generation.Set(float64(generation))
+	if err != nil {
+		c.reloads.WithLabelValues("failure").Inc()
+		return
+	}
+	c.reloads.WithLabelValues("success").Inc()
+	c.lastSuccess.SetToCurrentTime()
+}
+
+// WatchStarted implements koanfext.Metrics.
+func (c *Collector) WatchStarted(source string, err error) {
+	if err != nil {
+		c.watchFailures.WithLabelValues(source).Inc()
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prom.Desc) {
+	for _, collector := range c.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	for _, collector := range c.collectors() {
+		collector.Collect(ch)
+	}
+}
+
+func (c *Collector) collectors() []prom.Collector {
+	return []prom.Collector{
+		c.sourceAttempts,
+		c.sourceSuccesses,
+		c.sourceFailures,
+		c.sourceDuration,
+		c.sourceLastSuccess,
+		c.watchFailures,
+		c.reloads,
+		c.reloadDuration,
+		c.lastSuccess,
+		c.generation,
+	}
+}
@@ -0,0 +1,120 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/knadh/koanf/maps"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/jkratz55/koanfext"
+)
+
+// mutableProvider is a koanf.Provider whose configuration can be replaced, or
+// made to fail.
+type mutableProvider struct {
+	mu   sync.Mutex
+	conf map[string]interface{}
+	err  error
+}
+
+func (p *mutableProvider) set(conf map[string]interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.conf = conf
+}
+
+func (p *mutableProvider) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+func (p *mutableProvider) ReadBytes() ([]byte, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p *mutableProvider) Read() (map[string]interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return nil, p.err
+	}
+	return maps.Copy(p.conf), nil
+}
+
+// unwatchable is a koanfext.Watchable koanf.Provider failing to be watched.
+type unwatchable struct {
+	*mutableProvider
+}
+
+func (unwatchable) Watch(func(event koanfext.Event, err error)) error {
+	return errors.New("watch failed")
+}
+
+func TestCollector(t *testing.T) {
+	c := NewCollector()
+	if err := prom.NewPedanticRegistry().Register(c); err != nil {
+		t.Fatalf("expected the collector to be registered, got %v", err)
+	}
+
+	p := &mutableProvider{conf: map[string]interface{}{"name": "base"}}
+	k, err := koanfext.NewKoanfWrapper(
+		koanfext.Sources(koanfext.Source{Name: "base", Provider: p}),
+		koanfext.WithMetrics(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close(context.Background())
+
+	expect := func(name string, collector prom.Collector, want float64) {
+		t.Helper()
+		if got := testutil.ToFloat64(collector); got != want {
+			t.Fatalf("expected %s to be %v, got %v", name, want, got)
+		}
+	}
+
+	// Successful load
+	expect("attempts", c.sourceAttempts.WithLabelValues("base"), 1)
+	expect("successes", c.sourceSuccesses.WithLabelValues("base"), 1)
+	expect("failures", c.sourceFailures.WithLabelValues("base"), 0)
+	expect("successful reloads", c.reloads.WithLabelValues("success"), 1)
+	expect("failed reloads", c.reloads.WithLabelValues("failure"), 0)
+	expect("generation", c.generation, 1)
+	if testutil.ToFloat64(c.lastSuccess) == 0 {
+		t.Fatal("expected the last success timestamp to be set")
+	}
+
+	// Failed load
+	p.fail(errors.New("read failed"))
+	if _, err := k.Reload(context.Background()); err == nil {
+		t.Fatal("expected the reload to fail")
+	}
+	expect("attempts", c.sourceAttempts.WithLabelValues("base"), 2)
+	expect("successes", c.sourceSuccesses.WithLabelValues("base"), 1)
+	expect("failures", c.sourceFailures.WithLabelValues("base"), 1)
+	expect("successful reloads", c.reloads.WithLabelValues("success"), 1)
+	expect("failed reloads", c.reloads.WithLabelValues("failure"), 1)
+	expect("generation", c.generation, 1)
+
+	// Successful load once recovered
+	p.fail(nil)
+	p.set(map[string]interface{}{"name": "recovered"})
+	if _, err := k.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expect("successes", c.sourceSuccesses.WithLabelValues("base"), 2)
+	expect("successful reloads", c.reloads.WithLabelValues("success"), 2)
+	expect("generation", c.generation, 2)
+
+	// Failed watch start
+	w := unwatchable{&mutableProvider{conf: map[string]interface{}{"name": "watched"}}}
+	if err := k.AddSource("watched", koanfext.Source{Provider: w}, -1); err == nil {
+		t.Fatal("expected the source to fail to be watched")
+	}
+	expect("watch failures", c.watchFailures.WithLabelValues("watched"), 1)
+	expect("watch failures", c.watchFailures.WithLabelValues("base"), 0)
+}
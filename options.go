@@ -1,25 +1,147 @@
 package koanfext
 
+import (
+	"time"
+
+	"github.com/knadh/koanf/v2"
+)
+
 type Option func(*KoanfWrapper)
 
+// OnConfigChanged registers a function that is invoked each time a reload
+// successfully commits a new configuration. It is not invoked when a reload
+// fails. The Option may be used several times to register several functions,
+// which are invoked in order. Use KoanfWrapper.OnConfigChanged to register a
+// function that can be removed later.
 func OnConfigChanged(fn func()) Option {
 	return func(k *KoanfWrapper) {
-		if k.onConfigChanged != nil {
-			k.onConfigChanged = fn
+		if fn != nil {
+			k.onConfigChanged.add(fn)
+		}
+	}
+}
+
+// OnConfigChangedEvents registers a function that is invoked alongside
+// OnConfigChanged with the Events that triggered the reload. The Events are
+// empty when the reload wasn't triggered by a watch, such as a call to Reload.
+func OnConfigChangedEvents(fn func(events []Event)) Option {
+	return func(k *KoanfWrapper) {
+		if fn != nil {
+			k.onConfigChangedEvents.add(fn)
+		}
+	}
+}
+
+// OnChange registers a ChangeListener that is invoked after a reload with the
+// keys that were added, removed or modified, along with their old and new
+// values. Unlike OnConfigChanged the listener is only invoked when the reload
+// actually changed the configuration.
+func OnChange(fn ChangeListener) Option {
+	return func(k *KoanfWrapper) {
+		if fn != nil {
+			k.onChange.add(fn)
 		}
 	}
 }
 
+// OnError registers a function that is invoked when a Provider reports an
+// error while watching, or when a reload fails. Watch errors are reported as a
+// *WatchError and reload failures as a *ReloadError identifying the failing
+// Source. When several Sources fail their errors are joined, use errors.As to
+// inspect them. Panics recovered from listeners, Validators, Providers and
+// Parsers are reported as a *PanicError. Failures tolerated by the Policy of a
+// Source are reported as a *ReloadError with Tolerated set, even though the
+// configuration was committed.
+//
+// The Option may be used several times to register several functions, which
+// are invoked in order.
 func OnError(fn func(err error)) Option {
 	return func(k *KoanfWrapper) {
-		if k.onReloadError != nil {
-			k.onReloadError = fn
+		if fn != nil {
+			k.onError.add(fn)
 		}
 	}
 }
 
 func Sources(sources ...Source) Option {
 	return func(k *KoanfWrapper) {
-		k.sources = sources
+		k.sources = make([]*source, 0, len(sources))
+		for i, s := range sources {
+			k.sources = append(k.sources, newSource(s, i))
+		}
+	}
+}
+
+// ReloadDebounce configures KoanfWrapper to wait for a quiet period of d after
+// a watch event before reloading. Every event received during the quiet period
+// restarts it, so a burst of events, such as an editor saving a file or a
+// ConfigMap being added then updated, results in a single reload and a single
+// OnConfigChanged call. The reload only reads again the Sources whose Provider
+// reported an event during the burst, the other Sources are merged from the
+// configuration cached by the previous load.
+//
+// By default, debouncing is disabled and every event triggers a reload.
+func ReloadDebounce(d time.Duration) Option {
+	return func(k *KoanfWrapper) {
+		k.debounce = d
+	}
+}
+
+// Validators registers Validators that must all accept a candidate
+// configuration before it replaces the current one. Validators run in the
+// order they are provided, including on the initial load where a failure
+// causes NewKoanfWrapper to return an error. When a reload fails validation
+// KoanfWrapper keeps the last known good configuration and reports the error
+// through OnError.
+func Validators(validators ...Validator) Option {
+	return func(k *KoanfWrapper) {
+		k.validators = append(k.validators, validators...)
+	}
+}
+
+// Delimiter sets the delimiter used to separate the parts of a key path, "." by
+// default. A different delimiter is useful when keys themselves contain dots,
+// such as hostnames or ConfigMap keys like "app.properties", which would
+// otherwise be split into nested maps.
+func Delimiter(delim string) Option {
+	return func(k *KoanfWrapper) {
+		if delim != "" {
+			k.conf.Delim = delim
+		}
+	}
+}
+
+// KoanfConf sets the koanf.Conf applied to every koanf.Koanf instance built by
+// KoanfWrapper. If conf doesn't specify a delimiter the current one is kept.
+func KoanfConf(conf koanf.Conf) Option {
+	return func(k *KoanfWrapper) {
+		if conf.Delim == "" {
+			conf.Delim = k.conf.Delim
+		}
+		k.conf = conf
+	}
+}
+
+// ReloadQueue configures the queue feeding the worker that executes watch
+// triggered reloads. Up to size reloads can be queued while the worker is busy,
+// and policy determines what happens when a change is reported while the queue
+// is full. Since the worker merges every queued reload into one before
+// executing it, the queue only fills up while a reload is in progress.
+//
+// By default, the queue holds 16 reloads and uses OverflowCoalesce.
+func ReloadQueue(size int, policy OverflowPolicy) Option {
+	return func(k *KoanfWrapper) {
+		k.queueSize = size
+		k.overflowPolicy = policy
+	}
+}
+
+// WithMetrics configures KoanfWrapper to report measurements of its loads and
+// watches to m. By default, no measurements are reported.
+func WithMetrics(m Metrics) Option {
+	return func(k *KoanfWrapper) {
+		if m != nil {
+			k.metrics = m
+		}
 	}
 }
@@ -0,0 +1,236 @@
+package koanfext
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/knadh/koanf/v2"
+)
+
+var _ koanf.Provider = (*Poller)(nil)
+
+// Poller is a koanf.Provider decorator that makes any koanf.Provider Watchable
+// by periodically reading it and notifying when the content changes. This is
+// useful for Providers that can't detect changes on their own, such as a file
+// on an NFS mount where inotify events aren't delivered, or a MongoDB
+// deployment without change streams.
+type Poller struct {
+	provider koanf.Provider
+	interval time.Duration
+	jitter   time.Duration
+
+	mu sync.Mutex
+	// readMap is true when the Provider was last read with Read rather than
+	// ReadBytes, polling reads the Provider the same way.
+	readMap bool
+	hash    [sha256.Size]byte
+	// unhashed is the content last read with Read when it couldn't be hashed,
+	// in which case it is compared as is. It is nil otherwise.
+	unhashed map[string]interface{}
+
+	watched atomic.Uint32
+	stopCh  chan struct{}
+}
+
+// PollOption customizes the behavior of a Poller.
+type PollOption func(*Poller)
+
+// PollJitter adds a random delay between zero and d to every polling interval
+// so many instances polling the same backend don't do so in lockstep.
+func PollJitter(d time.Duration) PollOption {
+	return func(p *Poller) {
+		p.jitter = d
+	}
+}
+
+// Poll wraps provider in a Poller that reads it every interval once watched.
+func Poll(provider koanf.Provider, interval time.Duration, opts ...PollOption) *Poller {
+	if provider == nil {
+		panic("provider cannot be nil")
+	}
+	if interval <= 0 {
+		panic("interval must be greater than zero")
+	}
+
+	p := &Poller{
+		provider: provider,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// ReadBytes reads the raw bytes from the wrapped Provider.
+func (p *Poller) ReadBytes() ([]byte, error) {
+	data, err := p.provider.ReadBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.readMap = false
+	p.hash = sha256.Sum256(data)
+	p.unhashed = nil
+	p.mu.Unlock()
+	return data, nil
+}
+
+// Read reads the parsed configuration from the wrapped Provider.
+func (p *Poller) Read() (map[string]interface{}, error) {
+	conf, err := p.provider.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.readMap = true
+	p.setContent(conf)
+	p.mu.Unlock()
+	return conf, nil
+}
+
+// Watch starts polling the wrapped Provider and invokes the callback when its
+// content differs from the content last read. The Event reports the hash of
+// the new content as its Revision. If the content read with Read can't be
+// encoded as JSON to be hashed, it is compared deeply with the content last
+// read instead and changes are reported without a Revision. Errors reading the
+// Provider are passed to the callback and polling continues.
+//
+// Watch may only be invoked once per instance of Poller.
+func (p *Poller) Watch(cb func(event Event, err error)) error {
+	activated := p.watched.CompareAndSwap(0, 1)
+	if !activated {
+		return fmt.Errorf("%T.Watch may only be invoked once", p)
+	}
+
+	go func() {
+		timer := time.NewTimer(p.next())
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-p.stopCh:
+				return
+			case <-timer.C:
+			}
+
+			revision, changed, err := p.poll()
+			if err != nil {
+				cb(Event{Kind: EventError}, err)
+			} else if changed {
+				cb(Event{Kind: EventModified, Revision: revision}, nil)
+			}
+			timer.Reset(p.next())
+		}
+	}()
+
+	return nil
+}
+
+// Close stops polling and closes the wrapped Provider if it implements
+// io.Closer.
+func (p *Poller) Close() error {
+	// Transitioning to closed ensures stopCh is only closed once and Watch
+	// can't be invoked after Close.
+	if p.watched.Swap(2) != 2 {
+		close(p.stopCh)
+	}
+
+	if closer, ok := p.provider.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// poll reads the wrapped Provider and reports whether its content changed
+// since it was last read, along with the hex encoded hash of the content. A
+// panic in the wrapped Provider is recovered and returned as a *PanicError so
+// polling continues.
+func (p *Poller) poll() (revision string, changed bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			revision, changed, err = "", false, newPanicError(r)
+		}
+	}()
+
+	p.mu.Lock()
+	readMap := p.readMap
+	p.mu.Unlock()
+
+	if readMap {
+		var conf map[string]interface{}
+		if conf, err = p.provider.Read(); err != nil {
+			return "", false, err
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		// The content is recorded right away, so a change that hasn't been
+		// reloaded yet by the time of the next poll isn't reported twice.
+		previousHash, previous := p.hash, p.unhashed
+		p.setContent(conf)
+		if p.unhashed != nil {
+			return "", previous == nil || !reflect.DeepEqual(previous, conf), nil
+		}
+		if previous == nil && p.hash == previousHash {
+			return "", false, nil
+		}
+		return hex.EncodeToString(p.hash[:]), true, nil
+	}
+
+	var data []byte
+	if data, err = p.provider.ReadBytes(); err != nil {
+		return "", false, err
+	}
+	hash := sha256.Sum256(data)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if hash == p.hash {
+		return "", false, nil
+	}
+
+	// The hash is updated right away, so a change that hasn't been reloaded yet
+	// by the time of the next poll isn't reported twice.
+	p.hash = hash
+	return hex.EncodeToString(hash[:]), true, nil
+}
+
+// setContent records the content read with Read, by its hash if it can be
+// encoded as JSON or as is otherwise. mu must be held.
+func (p *Poller) setContent(conf map[string]interface{}) {
+	hash, err := hashMap(conf)
+	if err != nil {
+		p.hash, p.unhashed = [sha256.Size]byte{}, conf
+		return
+	}
+	p.hash, p.unhashed = hash, nil
+}
+
+func (p *Poller) next() time.Duration {
+	if p.jitter <= 0 {
+		return p.interval
+	}
+	return p.interval + rand.N(p.jitter)
+}
+
+// hashMap hashes the JSON encoding of conf, which is deterministic since maps
+// are encoded with their keys sorted.
+func hashMap(conf map[string]interface{}) ([sha256.Size]byte, error) {
+	data, err := json.Marshal(conf)
+	if err != nil {
+		return [sha256.Size]byte{}, fmt.Errorf("hash configuration: %w", err)
+	}
+	return sha256.Sum256(data), nil
+}
@@ -0,0 +1,203 @@
+package koanfext
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scriptedProvider is a koanf.Provider whose content and read error can be
+// changed while it is being polled.
+type scriptedProvider struct {
+	mu     sync.Mutex
+	data   []byte
+	err    error
+	reads  atomic.Int32
+	closed atomic.Bool
+}
+
+func (p *scriptedProvider) set(data string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.data, p.err = []byte(data), err
+}
+
+func (p *scriptedProvider) ReadBytes() ([]byte, error) {
+	p.reads.Add(1)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.data, p.err
+}
+
+func (p *scriptedProvider) Read() (map[string]interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p *scriptedProvider) Close() error {
+	p.closed.Store(true)
+	return nil
+}
+
+// pollEvent is an invocation of the callback passed to Poller.Watch.
+type pollEvent struct {
+	event Event
+	err   error
+}
+
+func watchPoller(t *testing.T, p *Poller) <-chan pollEvent {
+	t.Helper()
+	events := make(chan pollEvent, 16)
+	err := p.Watch(func(event Event, err error) {
+		events <- pollEvent{event: event, err: err}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func expectEvent(t *testing.T, events <-chan pollEvent) pollEvent {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("expected the poller to invoke the callback")
+		return pollEvent{}
+	}
+}
+
+func expectNoEvent(t *testing.T, events <-chan pollEvent, d time.Duration) {
+	t.Helper()
+	select {
+	case e := <-events:
+		t.Fatalf("expected no callback, got %v %v", e.event, e.err)
+	case <-time.After(d):
+	}
+}
+
+func TestPoller_NotifiesOnlyChanges(t *testing.T) {
+	sp := &scriptedProvider{}
+	sp.set(`{"a": 1}`, nil)
+	p := Poll(sp, 5*time.Millisecond)
+	defer p.Close()
+
+	if _, err := p.ReadBytes(); err != nil {
+		t.Fatal(err)
+	}
+	events := watchPoller(t, p)
+	expectNoEvent(t, events, 50*time.Millisecond)
+
+	sp.set(`{"a": 2}`, nil)
+	e := expectEvent(t, events)
+	if e.err != nil || e.event.Kind != EventModified || e.event.Revision == "" {
+		t.Fatalf("expected an EventModified event with a revision, got %v %v", e.event, e.err)
+	}
+
+	// The change is only reported once, even if it isn't read again
+	expectNoEvent(t, events, 50*time.Millisecond)
+
+	if err := p.Watch(func(Event, error) {}); err == nil {
+		t.Fatal("expected Watch to fail when invoked twice")
+	}
+}
+
+func TestPoller_ReportsErrorsAndContinues(t *testing.T) {
+	sp := &scriptedProvider{}
+	sp.set(`{"a": 1}`, nil)
+	p := Poll(sp, 5*time.Millisecond)
+	defer p.Close()
+
+	if _, err := p.ReadBytes(); err != nil {
+		t.Fatal(err)
+	}
+	events := watchPoller(t, p)
+
+	readErr := errors.New("connection refused")
+	sp.set(`{"a": 1}`, readErr)
+	e := expectEvent(t, events)
+	if e.event.Kind != EventError || !errors.Is(e.err, readErr) {
+		t.Fatalf("expected an EventError event, got %v %v", e.event, e.err)
+	}
+
+	sp.set(`{"a": 2}`, nil)
+	for {
+		e = expectEvent(t, events)
+		if e.event.Kind != EventError {
+			break
+		}
+	}
+	if e.err != nil || e.event.Kind != EventModified {
+		t.Fatalf("expected polling to continue after an error, got %v %v", e.event, e.err)
+	}
+}
+
+// unencodableProvider is a koanf.Provider whose configuration can't be
+// encoded as JSON since it holds an infinite value.
+type unencodableProvider struct {
+	limit atomic.Value
+}
+
+func (p *unencodableProvider) ReadBytes() ([]byte, error) {
+	return nil, errors.New("not supported")
+}
+
+func (p *unencodableProvider) Read() (map[string]interface{}, error) {
+	return map[string]interface{}{"limit": p.limit.Load()}, nil
+}
+
+func TestPoller_UnencodableContent(t *testing.T) {
+	up := &unencodableProvider{}
+	up.limit.Store(math.Inf(1))
+	p := Poll(up, 5*time.Millisecond)
+	defer p.Close()
+
+	conf, err := p.Read()
+	if err != nil {
+		t.Fatalf("expected the content to be read, got %v", err)
+	}
+	if got := conf["limit"]; got != math.Inf(1) {
+		t.Fatalf("expected the content of the provider, got %v", conf)
+	}
+
+	// The content is compared as is since it can't be hashed
+	events := watchPoller(t, p)
+	expectNoEvent(t, events, 50*time.Millisecond)
+
+	up.limit.Store(math.Inf(-1))
+	e := expectEvent(t, events)
+	if e.err != nil || e.event.Kind != EventModified || e.event.Revision != "" {
+		t.Fatalf("expected an EventModified event without a revision, got %v %v", e.event, e.err)
+	}
+	expectNoEvent(t, events, 50*time.Millisecond)
+}
+
+func TestPoller_CloseStopsPolling(t *testing.T) {
+	sp := &scriptedProvider{}
+	sp.set(`{"a": 1}`, nil)
+	p := Poll(sp, 5*time.Millisecond)
+	watchPoller(t, p)
+
+	time.Sleep(20 * time.Millisecond)
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !sp.closed.Load() {
+		t.Fatal("expected the wrapped provider to be closed")
+	}
+
+	// A poll that was in progress when Close was called may still complete
+	time.Sleep(10 * time.Millisecond)
+	reads := sp.reads.Load()
+	time.Sleep(50 * time.Millisecond)
+	if got := sp.reads.Load(); got != reads {
+		t.Fatalf("expected polling to stop once closed, got %d reads after %d", got, reads)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("expected Close to be safe to call twice, got %v", err)
+	}
+}
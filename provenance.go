@@ -0,0 +1,76 @@
+package koanfext
+
+import (
+	"github.com/knadh/koanf/maps"
+	"github.com/knadh/koanf/v2"
+)
+
+// Origin identifies the Source that supplied the effective value of a key.
+// Source is the name of the Source and Index its position among the Sources.
+//
+// A slice combined from several Sources with MergeAppend or MergeUnion has a
+// single Origin, the last Source supplying the key, even though the elements
+// of the slice come from every Source that supplied it.
+type Origin struct {
+	Source string
+	Index  int
+}
+
+// provenance determines the Origin of every key in conf. Layers are merged in
+// order with later layers taking precedence, so the Origin of a key is the last
+// Source whose layer contains it. Keys that were overridden by a later layer
+// replacing a map with a scalar, or the other way around, are not present in
+// conf and are omitted.
+func provenance(conf *koanf.Koanf, sources []*source, layers []map[string]interface{}) map[string]Origin {
+	origins := make(map[string]Origin)
+	for i, layer := range layers {
+		if layer == nil {
+			continue
+		}
+		flat, _ := maps.Flatten(layer, nil, conf.Delim())
+		for key := range flat {
+			origins[key] = Origin{Source: sources[i].name, Index: i}
+		}
+	}
+
+	// Exists is also true for the parents of a key, so a key a later layer
+	// turned into a map is only dropped by checking against the leaf keys.
+	leaves := conf.All()
+	for key := range origins {
+		if _, ok := leaves[key]; !ok {
+			delete(origins, key)
+		}
+	}
+	return origins
+}
+
+// Origin returns the Origin of the effective value of the given key path. Only
+// leaf keys, as returned by Keys, have an Origin. The bool is false if the key
+// does not exist. A slice merged from several Sources is attributed to the
+// last of them.
+func (s *Snapshot) Origin(path string) (Origin, bool) {
+	origin, ok := s.origins[path]
+	return origin, ok
+}
+
+// Provenance returns a copy of the Origin of every key, keyed by the flattened
+// key path. Like Origin, a slice merged from several Sources is attributed to
+// the last of them.
+func (s *Snapshot) Provenance() map[string]Origin {
+	out := make(map[string]Origin, len(s.origins))
+	for key, origin := range s.origins {
+		out[key] = origin
+	}
+	return out
+}
+
+// Origin returns the Origin of the effective value of the given key path in
+// the current Snapshot.
+func (k *KoanfWrapper) Origin(path string) (Origin, bool) {
+	return k.Snapshot().Origin(path)
+}
+
+// Provenance returns the Origin of every key in the current Snapshot.
+func (k *KoanfWrapper) Provenance() map[string]Origin {
+	return k.Snapshot().Provenance()
+}
@@ -1,13 +1,17 @@
 package file
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sync/atomic"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/knadh/koanf/v2"
+
+	"github.com/jkratz55/koanfext"
 )
 
 var _ koanf.Provider = (*File)(nil)
@@ -40,12 +44,19 @@ func (f *File) Read() (map[string]interface{}, error) {
 	return nil, fmt.Errorf("%T does not support Read()", f)
 }
 
-// Watch monitors the file for changes and invokes the provided callback when
-// changes are detected.
+// Watch monitors the file for changes and invokes the provided callback with a
+// koanfext.Event when changes are detected. The Payload of the Event is the
+// fsnotify.Event that triggered it. Removing the file is reported by a
+// koanfext.EventDeleted Event. If the fsnotify watcher is closed other than by
+// Close, the callback is invoked with a koanfext.EventStopped Event.
+//
+// The directory containing the file is watched, so the file doesn't need to
+// exist when Watch is invoked and watching continues after it is removed. Once
+// it is created the callback is invoked with a koanfext.EventRecreated Event.
 //
 // Watch may only be invoked once per instance of File and providing a nil callback
 // will result in a panic.
-func (f *File) Watch(cb func(event interface{}, err error)) error {
+func (f *File) Watch(cb func(event koanfext.Event, err error)) error {
 	activated := f.watched.CompareAndSwap(0, 1)
 	if !activated {
 		return fmt.Errorf("%T.Watch may only be invoked once", f)
@@ -53,8 +64,10 @@ func (f *File) Watch(cb func(event interface{}, err error)) error {
 
 	configFile := filepath.Clean(f.path)
 	configDir, _ := filepath.Split(configFile)
+	// The file is resolved lazily since it may not exist yet, such as an
+	// optional override that is created later.
 	realConfigFile, err := filepath.EvalSymlinks(f.path)
-	if err != nil {
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		return err
 	}
 
@@ -63,50 +76,69 @@ func (f *File) Watch(cb func(event interface{}, err error)) error {
 		return err
 	}
 
+	f.watcher = watcher
+
 	go func() {
 		defer watcher.Close()
 		for {
 			select {
 			case event, ok := <-watcher.Events:
 				if !ok {
-					cb(nil, fmt.Errorf("fsnotify watcher closed"))
+					// The watcher is closed by Close, if that wasn't the case the
+					// watch was terminated unexpectedly.
+					if f.watched.Load() == 1 {
+						cb(koanfext.Event{Kind: koanfext.EventStopped}, fmt.Errorf("fsnotify watcher closed"))
+					}
 					return
 				}
 
+				if filepath.Clean(event.Name) == configFile && event.Has(fsnotify.Remove) {
+					// The directory is still watched, so the file being created
+					// again is reported as EventRecreated.
+					cb(koanfext.Event{Kind: koanfext.EventDeleted, Payload: event}, nil)
+					continue
+				}
+
 				currentConfigFile, err := filepath.EvalSymlinks(f.path)
-				if err != nil {
-					cb(nil, err)
+				if err != nil && !errors.Is(err, fs.ErrNotExist) {
+					cb(koanfext.Event{Kind: koanfext.EventError, Payload: event}, err)
 					continue
 				}
 
 				// If the filename matches the file being monitored and the file
-				// was either created than notify the file has changed so the caller
-				// can decided if they want to refresh the configuration.
-				if (filepath.Clean(event.Name) == configFile &&
-					(event.Has(fsnotify.Write) || event.Has(fsnotify.Create))) ||
-					(currentConfigFile != "" && currentConfigFile != realConfigFile) {
-					cb(event, nil)
-				} else if filepath.Clean(event.Name) == configFile && event.Has(fsnotify.Remove) {
-					cb(nil, fmt.Errorf("file %s was removed", event.Name))
-					return
+				// was either written or created, or the symlink now points to
+				// a different file, notify the file has changed so the caller
+				// can decide if they want to refresh the configuration.
+				switch {
+				case filepath.Clean(event.Name) == configFile && event.Has(fsnotify.Write):
+					cb(koanfext.Event{Kind: koanfext.EventModified, Payload: event}, nil)
+				case filepath.Clean(event.Name) == configFile && event.Has(fsnotify.Create):
+					if currentConfigFile != "" {
+						realConfigFile = currentConfigFile
+					}
+					cb(koanfext.Event{Kind: koanfext.EventRecreated, Payload: event}, nil)
+				case currentConfigFile != "" && currentConfigFile != realConfigFile:
+					realConfigFile = currentConfigFile
+					cb(koanfext.Event{Kind: koanfext.EventRecreated, Payload: event}, nil)
 				}
 			case err, ok := <-watcher.Errors:
 				if ok {
-					cb(nil, err)
+					cb(koanfext.Event{Kind: koanfext.EventError}, err)
 				}
 			}
 		}
 	}()
 
-	f.watcher = watcher
 	return watcher.Add(configDir)
 }
 
 // Close gracefully closes and releases any resources File is using.
 func (f *File) Close() error {
-	if f.watcher != nil {
+	// Transitioning from watched to closed lets the watch tell Close apart from
+	// the watcher being closed unexpectedly. If Watch was never called this is
+	// a no-op.
+	if f.watched.CompareAndSwap(1, 2) && f.watcher != nil {
 		return f.watcher.Close()
 	}
-	// If Watch was never called this is a no-op
 	return nil
 }
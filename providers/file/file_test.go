@@ -0,0 +1,155 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jkratz55/koanfext"
+)
+
+// jsonParser is a minimal koanf.Parser decoding JSON.
+type jsonParser struct{}
+
+func (jsonParser) Unmarshal(b []byte) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	err := json.Unmarshal(b, &out)
+	return out, err
+}
+
+func (jsonParser) Marshal(o map[string]interface{}) ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFile_MissingOptionalOverride(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.json")
+	override := filepath.Join(dir, "override.json")
+	writeFile(t, base, `{"name": "base", "port": 8080}`)
+
+	k, err := koanfext.NewKoanfWrapper(koanfext.Sources(
+		koanfext.Source{Name: "base", Provider: Provider(base), Parser: jsonParser{}},
+		koanfext.Source{Name: "override", Provider: Provider(override), Parser: jsonParser{},
+			Policy: koanfext.Optional}))
+	if err != nil {
+		t.Fatalf("expected the missing override to be skipped, got %v", err)
+	}
+	defer k.Close(context.Background())
+
+	if got := k.String("name"); got != "base" {
+		t.Fatalf("expected name to be base, got %q", got)
+	}
+	for _, s := range k.Status() {
+		if !s.Watching {
+			t.Fatalf("expected source %s to be watched", s.Name)
+		}
+	}
+
+	// The override is picked up once it is created
+	changed := make(chan struct{}, 1)
+	k.OnConfigChanged(func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	writeFile(t, override, `{"name": "override"}`)
+
+	deadline := time.After(5 * time.Second)
+	for k.String("name") != "override" {
+		select {
+		case <-changed:
+		case <-deadline:
+			t.Fatalf("expected the override to be loaded once created, got name %q", k.String("name"))
+		}
+	}
+	if got := k.Int("port"); got != 8080 {
+		t.Fatalf("expected port to be 8080, got %d", got)
+	}
+}
+
+func TestFile_OptionalOverrideDeletedAndRecreated(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.json")
+	override := filepath.Join(dir, "override.json")
+	writeFile(t, base, `{"name": "base"}`)
+	writeFile(t, override, `{"name": "override"}`)
+
+	k, err := koanfext.NewKoanfWrapper(koanfext.Sources(
+		koanfext.Source{Name: "base", Provider: Provider(base), Parser: jsonParser{}},
+		koanfext.Source{Name: "override", Provider: Provider(override), Parser: jsonParser{},
+			Policy: koanfext.Optional}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close(context.Background())
+
+	if got := k.String("name"); got != "override" {
+		t.Fatalf("expected name to be override, got %q", got)
+	}
+
+	changed := make(chan struct{}, 1)
+	k.OnConfigChanged(func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	waitForName := func(want string) {
+		t.Helper()
+		deadline := time.After(5 * time.Second)
+		for k.String("name") != want {
+			select {
+			case <-changed:
+			case <-deadline:
+				t.Fatalf("expected name to be %q, got %q", want, k.String("name"))
+			}
+		}
+	}
+
+	// Deleting the override falls back to the base
+	if err := os.Remove(override); err != nil {
+		t.Fatal(err)
+	}
+	waitForName("base")
+
+	// Recreating the override is picked up since it is still watched
+	writeFile(t, override, `{"name": "recreated"}`)
+	waitForName("recreated")
+
+	for _, s := range k.Status() {
+		if !s.Watching {
+			t.Fatalf("expected source %s to be watched", s.Name)
+		}
+	}
+}
+
+func TestFile_CloseDoesNotStop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{}`)
+
+	f := Provider(path)
+	events := make(chan koanfext.Event, 1)
+	if err := f.Watch(func(event koanfext.Event, err error) { events <- event }); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case e := <-events:
+		t.Fatalf("expected no event once closed, got %v", e)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
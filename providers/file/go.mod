@@ -4,6 +4,7 @@ go 1.23.5
 
 require (
 	github.com/fsnotify/fsnotify v1.8.0
+	github.com/jkratz55/koanfext v0.1.0
 	github.com/knadh/koanf/v2 v2.1.2
 )
 
@@ -14,3 +15,9 @@ require (
 	github.com/mitchellh/reflectwalk v1.0.2 // indirect
 	golang.org/x/sys v0.26.0 // indirect
 )
+
+// v0.1.0 is the first tag of the root module, cut once the Event API used by
+// this module is merged. It must be pushed before this module is published.
+// The replace directive builds against the root module of this repository
+// during development, it is ignored by modules depending on this one.
+replace github.com/jkratz55/koanfext => ../..
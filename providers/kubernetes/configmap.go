@@ -7,10 +7,13 @@ import (
 
 	"github.com/knadh/koanf/v2"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
+
+	"github.com/jkratz55/koanfext"
 )
 
 var _ koanf.Provider = (*ConfigMap)(nil)
@@ -49,6 +52,9 @@ func (c *ConfigMap) ReadBytes() ([]byte, error) {
 func (c *ConfigMap) Read() (map[string]interface{}, error) {
 	cm, err := c.client.CoreV1().ConfigMaps(c.namespace).Get(context.Background(), c.name, metav1.GetOptions{})
 	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: %w", koanfext.ErrNotExist, err)
+		}
 		return nil, err
 	}
 
@@ -60,10 +66,12 @@ func (c *ConfigMap) Read() (map[string]interface{}, error) {
 }
 
 // Watch sets up a listener to monitor changes in the ConfigMap and invokes the
-// callback upon add or update events. It ensures the method can only be invoked once
+// callback with a koanfext.Event upon add, update or delete events. The Event
+// carries the resourceVersion of the ConfigMap as its Revision and the
+// *corev1.ConfigMap as its Payload. It ensures the method can only be invoked once
 // and blocks until the cache syncs successfully. Returns an error if the watch
 // activation fails or cache synchronization times out.
-func (c *ConfigMap) Watch(cb func(event interface{}, err error)) error {
+func (c *ConfigMap) Watch(cb func(event koanfext.Event, err error)) error {
 	activated := c.watched.CompareAndSwap(0, 1)
 	if !activated {
 		return fmt.Errorf("%T.Watch may only be invoked once", c)
@@ -77,17 +85,35 @@ func (c *ConfigMap) Watch(cb func(event interface{}, err error)) error {
 	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			cm := obj.(*corev1.ConfigMap)
-			cb(cm, nil)
+			cb(configMapEvent(koanfext.EventRecreated, cm), nil)
 		},
 		UpdateFunc: func(old, new interface{}) {
 			cm := new.(*corev1.ConfigMap)
-			cb(cm, nil)
+			cb(configMapEvent(koanfext.EventModified, cm), nil)
+		},
+		DeleteFunc: func(obj interface{}) {
+			// The final state of the ConfigMap may be unknown if the deletion
+			// was missed while disconnected from the API server.
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			cm, _ := obj.(*corev1.ConfigMap)
+			cb(configMapEvent(koanfext.EventDeleted, cm), nil)
 		},
 	})
 	if err != nil {
 		return err
 	}
 
+	// The informer retries failed watches on its own, the errors are reported
+	// so they aren't silently swallowed while the ConfigMap isn't watched.
+	err = informer.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		cb(koanfext.Event{Kind: koanfext.EventError}, err)
+	})
+	if err != nil {
+		return err
+	}
+
 	go informer.Run(c.stopCh)
 
 	if !cache.WaitForCacheSync(c.stopCh, informer.HasSynced) {
@@ -98,9 +124,23 @@ func (c *ConfigMap) Watch(cb func(event interface{}, err error)) error {
 }
 
 // Close gracefully closes a ConfigMap watch if Watch was called. Otherwise, it
-// is a no-op.
-func (c *ConfigMap) Close() {
-	if c.watched.Load() == 1 {
+// is a no-op. Close always returns nil, the error is only present to satisfy
+// io.Closer.
+func (c *ConfigMap) Close() error {
+	// Transitioning from watched to closed ensures stopCh is only closed once
+	// and Watch can't be invoked after Close.
+	if c.watched.CompareAndSwap(1, 2) {
 		close(c.stopCh)
 	}
+	return nil
+}
+
+// configMapEvent builds a koanfext.Event for a change to a ConfigMap, which may
+// be nil if it's unknown.
+func configMapEvent(kind koanfext.EventKind, cm *corev1.ConfigMap) koanfext.Event {
+	event := koanfext.Event{Kind: kind, Payload: cm}
+	if cm != nil {
+		event.Revision = cm.ResourceVersion
+	}
+	return event
 }
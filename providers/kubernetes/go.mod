@@ -3,6 +3,7 @@ module github.com/jkratz55/koanfext/providers/kubernetes
 go 1.23.5
 
 require (
+	github.com/jkratz55/koanfext v0.1.0
 	github.com/knadh/koanf/v2 v2.1.2
 	k8s.io/api v0.32.1
 	k8s.io/apimachinery v0.32.1
@@ -52,3 +53,9 @@ require (
 	sigs.k8s.io/structured-merge-diff/v4 v4.4.2 // indirect
 	sigs.k8s.io/yaml v1.4.0 // indirect
 )
+
+// v0.1.0 is the first tag of the root module, cut once the Event API used by
+// this module is merged. It must be pushed before this module is published.
+// The replace directive builds against the root module of this repository
+// during development, it is ignored by modules depending on this one.
+replace github.com/jkratz55/koanfext => ../..
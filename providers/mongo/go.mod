@@ -3,6 +3,7 @@ module github.com/jkratz55/koanfext/providers/mongo
 go 1.23.5
 
 require (
+	github.com/jkratz55/koanfext v0.1.0
 	github.com/knadh/koanf/v2 v2.1.2
 	go.mongodb.org/mongo-driver v1.17.2
 )
@@ -24,3 +25,9 @@ require (
 	golang.org/x/sync v0.8.0 // indirect
 	golang.org/x/text v0.19.0 // indirect
 )
+
+// v0.1.0 is the first tag of the root module, cut once the Event API used by
+// this module is merged. It must be pushed before this module is published.
+// The replace directive builds against the root module of this repository
+// during development, it is ignored by modules depending on this one.
+replace github.com/jkratz55/koanfext => ../..
@@ -2,12 +2,16 @@ package mongo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync/atomic"
 
 	"github.com/knadh/koanf/v2"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/jkratz55/koanfext"
 )
 
 var _ koanf.Provider = (*MongoDB)(nil)
@@ -47,6 +51,12 @@ func (m *MongoDB) ReadBytes() ([]byte, error) {
 	var result bson.M
 	err := collection.FindOne(context.Background(), filter).Decode(&result)
 	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, fmt.Errorf("document %v: %w", m.documentID, koanfext.ErrNotExist)
+		case mongo.IsNetworkError(err), mongo.IsTimeout(err):
+			return nil, fmt.Errorf("%w: %w", koanfext.ErrUnavailable, err)
+		}
 		return nil, err
 	}
 
@@ -63,8 +73,11 @@ func (m *MongoDB) Read() (map[string]interface{}, error) {
 }
 
 // Watch sets up a change stream to monitor a specific MongoDB document for updates
-// and invokes the callback on changes.
-func (m *MongoDB) Watch(cb func(event interface{}, err error)) error {
+// and invokes the callback with a koanfext.Event on changes. The Event carries
+// the cluster time of the change as its Revision and the change event as its
+// Payload. If the change stream is closed other than by Close, the callback is
+// invoked with a koanfext.EventStopped Event.
+func (m *MongoDB) Watch(cb func(event koanfext.Event, err error)) error {
 	activated := m.watched.CompareAndSwap(0, 1)
 	if !activated {
 		return fmt.Errorf("%T.Watch may only be invoked once", m)
@@ -89,22 +102,41 @@ func (m *MongoDB) Watch(cb func(event interface{}, err error)) error {
 		for m.changeStream.Next(context.Background()) {
 			var event bson.M
 			if err := m.changeStream.Decode(&event); err != nil {
-				cb(nil, err)
+				cb(koanfext.Event{Kind: koanfext.EventError}, err)
+				continue
 			}
 
 			operation, ok := event["operationType"].(string)
 			if !ok {
-				cb(nil, fmt.Errorf("failed to parse operation type: %v", event))
+				cb(koanfext.Event{Kind: koanfext.EventError, Payload: event},
+					fmt.Errorf("failed to parse operation type: %v", event))
 				continue
 			}
 
-			// If the document holding configuration is deleted we likely don't want
-			// the application to reload its configuration since it is doomed to fail
-			if operation == "delete" {
-				continue
+			kind := koanfext.EventModified
+			switch operation {
+			case "delete":
+				kind = koanfext.EventDeleted
+			case "insert", "replace":
+				kind = koanfext.EventRecreated
 			}
 
-			cb(event, nil)
+			var revision string
+			if ts, ok := event["clusterTime"].(primitive.Timestamp); ok {
+				revision = fmt.Sprintf("%d.%d", ts.T, ts.I)
+			}
+
+			cb(koanfext.Event{Kind: kind, Revision: revision, Payload: event}, nil)
+		}
+
+		// Next only returns false once the change stream is closed or has
+		// failed, if that wasn't caused by Close the watch has stopped.
+		if m.watched.Load() == 1 {
+			err := fmt.Errorf("change stream closed")
+			if streamErr := m.changeStream.Err(); streamErr != nil {
+				err = fmt.Errorf("change stream closed: %w", streamErr)
+			}
+			cb(koanfext.Event{Kind: koanfext.EventStopped}, err)
 		}
 	}()
 
@@ -114,7 +146,9 @@ func (m *MongoDB) Watch(cb func(event interface{}, err error)) error {
 // Close terminates the MongoDB change stream if active and returns any encountered
 // error during closure.
 func (m *MongoDB) Close() error {
-	if m.watched.Load() == 1 && m.changeStream != nil {
+	// Transitioning from watched to closed lets the watch tell Close apart from
+	// the change stream failing
+	if m.watched.CompareAndSwap(1, 2) && m.changeStream != nil {
 		return m.changeStream.Close(context.Background())
 	}
 	return nil
@@ -3,6 +3,7 @@ module github.com/jkratz55/koanfext/providers/redis
 go 1.23.5
 
 require (
+	github.com/jkratz55/koanfext v0.1.0
 	github.com/knadh/koanf/v2 v2.1.2
 	github.com/redis/go-redis/v9 v9.7.0
 )
@@ -15,3 +16,9 @@ require (
 	github.com/mitchellh/copystructure v1.2.0 // indirect
 	github.com/mitchellh/reflectwalk v1.0.2 // indirect
 )
+
+// v0.1.0 is the first tag of the root module, cut once the Event API used by
+// this module is merged. It must be pushed before this module is published.
+// The replace directive builds against the root module of this repository
+// during development, it is ignored by modules depending on this one.
+replace github.com/jkratz55/koanfext => ../..
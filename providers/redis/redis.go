@@ -8,6 +8,8 @@ import (
 
 	"github.com/knadh/koanf/v2"
 	"github.com/redis/go-redis/v9"
+
+	"github.com/jkratz55/koanfext"
 )
 
 var _ koanf.Provider = (*Redis)(nil)
@@ -39,7 +41,7 @@ func (r *Redis) ReadBytes() ([]byte, error) {
 	data, err := r.client.Get(context.Background(), r.key).Bytes()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
-			return nil, fmt.Errorf("key %s does not exist", r.key)
+			return nil, fmt.Errorf("key %s: %w", r.key, koanfext.ErrNotExist)
 		}
 		return nil, err
 	}
@@ -52,11 +54,15 @@ func (r *Redis) Read() (map[string]interface{}, error) {
 }
 
 // Watch utilizes Redis keyspace events to detect when a key has been modified
-// and invokes the callback.
+// and invokes the callback with a koanfext.Event. The Payload of the Event is
+// the keyspace event, such as "set" or "del".
+//
+// If the subscription is terminated other than by Close, the callback is
+// invoked with a koanfext.EventStopped Event.
 //
 // Since Watch relies on Redis keyspace events ensure it is enabled in the Redis
 // or Watch will not behave as expected.
-func (r *Redis) Watch(cb func(event interface{}, err error)) error {
+func (r *Redis) Watch(cb func(event koanfext.Event, err error)) error {
 	activated := r.watched.CompareAndSwap(0, 1)
 	if !activated {
 		return fmt.Errorf("%T.Watch may only be invoked once", r)
@@ -68,12 +74,18 @@ func (r *Redis) Watch(cb func(event interface{}, err error)) error {
 
 	go func() {
 		for msg := range r.changeChan {
-			if msg.Payload == "del" {
-				cb(nil, fmt.Errorf("configuration key deleted"))
-				continue
+			switch msg.Payload {
+			case "del", "expired", "evicted":
+				cb(koanfext.Event{Kind: koanfext.EventDeleted, Payload: msg.Payload}, nil)
+			default:
+				cb(koanfext.Event{Kind: koanfext.EventModified, Payload: msg.Payload}, nil)
 			}
+		}
 
-			cb(msg.Payload, nil)
+		// The channel is closed when the subscription is closed, if that wasn't
+		// done by Close the watch was terminated unexpectedly.
+		if r.watched.Load() == 1 {
+			cb(koanfext.Event{Kind: koanfext.EventStopped}, fmt.Errorf("subscription to key %s closed", r.key))
 		}
 	}()
 
@@ -82,7 +94,9 @@ func (r *Redis) Watch(cb func(event interface{}, err error)) error {
 
 // Close cleans up any resources and stops the watch if one was active.
 func (r *Redis) Close() error {
-	if r.watched.Load() == 1 && r.pubsub != nil {
+	// Transitioning from watched to closed lets the watch tell Close apart from
+	// the subscription being terminated unexpectedly
+	if r.watched.CompareAndSwap(1, 2) && r.pubsub != nil {
 		return r.pubsub.Close()
 	}
 	return nil
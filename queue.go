@@ -0,0 +1,157 @@
+package koanfext
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// OverflowPolicy determines what happens when a Provider reports a change while
+// the reload queue is full.
+type OverflowPolicy uint8
+
+const (
+	// OverflowCoalesce merges the change into the most recently queued reload.
+	// No change is lost and Providers never block. This is the default
+	// OverflowPolicy.
+	OverflowCoalesce OverflowPolicy = iota
+
+	// OverflowDropOldest discards the oldest queued reload to make room for the
+	// change and reports ErrReloadDropped through OnError. The Sources the
+	// discarded reload would have read aren't read again until they report
+	// another change or Reload is called.
+	OverflowDropOldest
+
+	// OverflowBlock blocks the Provider reporting the change until there is
+	// room in the queue. Only use OverflowBlock with Providers that tolerate
+	// their callback blocking.
+	OverflowBlock
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowCoalesce:
+		return "coalesce"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowBlock:
+		return "block"
+	default:
+		return fmt.Sprintf("OverflowPolicy(%d)", p)
+	}
+}
+
+// ErrReloadDropped is reported through OnError when a queued reload is
+// discarded by OverflowDropOldest.
+var ErrReloadDropped = errors.New("reload dropped, reload queue is full")
+
+// defaultQueueSize is the number of reloads that can be queued when the
+// ReloadQueue Option isn't used.
+const defaultQueueSize = 16
+
+// reloadRequest is a queued reload of the Sources in refresh, triggered by
+// events.
+type reloadRequest struct {
+	refresh map[*source]bool
+	events  []Event
+}
+
+// merge folds other into r so a single reload covers both.
+func (r *reloadRequest) merge(other reloadRequest) {
+	if r.refresh == nil {
+		r.refresh = make(map[*source]bool, len(other.refresh))
+	}
+	for src := range other.refresh {
+		r.refresh[src] = true
+	}
+	r.events = append(r.events, other.events...)
+}
+
+// reloadQueue is a bounded FIFO of reloadRequest feeding the reload worker.
+type reloadQueue struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	items  []reloadRequest
+	size   int
+	policy OverflowPolicy
+	closed bool
+}
+
+func newReloadQueue(size int, policy OverflowPolicy) *reloadQueue {
+	if size < 1 {
+		size = 1
+	}
+	q := &reloadQueue{
+		items:  make([]reloadRequest, 0, size),
+		size:   size,
+		policy: policy,
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push queues req, applying the OverflowPolicy if the queue is full. It
+// returns true if a queued request was discarded to make room for req.
+func (q *reloadQueue) push(req reloadRequest) (dropped bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return false
+	}
+
+	if len(q.items) >= q.size {
+		switch q.policy {
+		case OverflowDropOldest:
+			q.items = append(q.items[:0], q.items[1:]...)
+			dropped = true
+		case OverflowBlock:
+			for len(q.items) >= q.size && !q.closed {
+				q.cond.Wait()
+			}
+			if q.closed {
+				return false
+			}
+		default:
+			q.items[len(q.items)-1].merge(req)
+			return false
+		}
+	}
+
+	q.items = append(q.items, req)
+	q.cond.Broadcast()
+	return dropped
+}
+
+// pop blocks until at least one request is queued and returns every queued
+// request merged into one, since a single reload covers them all. It returns
+// false once the queue is closed.
+func (q *reloadQueue) pop() (reloadRequest, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.items) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return reloadRequest{}, false
+	}
+
+	var req reloadRequest
+	for _, item := range q.items {
+		req.merge(item)
+	}
+	q.items = q.items[:0]
+	q.cond.Broadcast()
+	return req, true
+}
+
+// close discards the queued requests and releases the worker and any blocked
+// producers.
+func (q *reloadQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.items = nil
+	q.cond.Broadcast()
+}
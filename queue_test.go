@@ -0,0 +1,148 @@
+package koanfext
+
+import (
+	"testing"
+	"time"
+)
+
+// request returns a reloadRequest refreshing src, triggered by a single Event
+// carrying payload.
+func request(src *source, payload interface{}) reloadRequest {
+	return reloadRequest{
+		refresh: map[*source]bool{src: true},
+		events:  []Event{{Kind: EventModified, Payload: payload}},
+	}
+}
+
+// payloads returns the Payload of every Event of req in order.
+func payloads(req reloadRequest) []interface{} {
+	out := make([]interface{}, 0, len(req.events))
+	for _, e := range req.events {
+		out = append(out, e.Payload)
+	}
+	return out
+}
+
+func assertPayloads(t *testing.T, req reloadRequest, want ...interface{}) {
+	t.Helper()
+	got := payloads(req)
+	if len(got) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected events %v, got %v", want, got)
+		}
+	}
+}
+
+func TestReloadQueue_Coalesce(t *testing.T) {
+	q := newReloadQueue(2, OverflowCoalesce)
+	a, b, c := &source{}, &source{}, &source{}
+
+	for i, src := range []*source{a, b, c} {
+		if dropped := q.push(request(src, i)); dropped {
+			t.Fatalf("push %d: expected no request to be dropped", i)
+		}
+	}
+	if len(q.items) != 2 {
+		t.Fatalf("expected the overflowing request to be merged into the last one, got %d queued", len(q.items))
+	}
+	if !q.items[1].refresh[b] || !q.items[1].refresh[c] {
+		t.Fatalf("expected the last queued request to refresh both sources")
+	}
+
+	req, ok := q.pop()
+	if !ok {
+		t.Fatal("expected pop to return a request")
+	}
+	if len(req.refresh) != 3 {
+		t.Fatalf("expected every source to be refreshed, got %d", len(req.refresh))
+	}
+	assertPayloads(t, req, 0, 1, 2)
+}
+
+func TestReloadQueue_DropOldest(t *testing.T) {
+	q := newReloadQueue(2, OverflowDropOldest)
+	a, b, c := &source{}, &source{}, &source{}
+
+	q.push(request(a, 0))
+	q.push(request(b, 1))
+	if dropped := q.push(request(c, 2)); !dropped {
+		t.Fatal("expected the oldest request to be dropped")
+	}
+
+	req, ok := q.pop()
+	if !ok {
+		t.Fatal("expected pop to return a request")
+	}
+	if req.refresh[a] || !req.refresh[b] || !req.refresh[c] {
+		t.Fatalf("expected only the two most recent sources to be refreshed, got %v", req.refresh)
+	}
+	assertPayloads(t, req, 1, 2)
+}
+
+func TestReloadQueue_Block(t *testing.T) {
+	q := newReloadQueue(1, OverflowBlock)
+	q.push(request(&source{}, 0))
+
+	pushed := make(chan bool)
+	go func() {
+		pushed <- q.push(request(&source{}, 1))
+	}()
+
+	select {
+	case <-pushed:
+		t.Fatal("expected push to block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	req, ok := q.pop()
+	if !ok {
+		t.Fatal("expected pop to return a request")
+	}
+	assertPayloads(t, req, 0)
+
+	select {
+	case dropped := <-pushed:
+		if dropped {
+			t.Fatal("expected no request to be dropped")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected push to unblock once the queue had room")
+	}
+
+	req, ok = q.pop()
+	if !ok {
+		t.Fatal("expected pop to return a request")
+	}
+	assertPayloads(t, req, 1)
+}
+
+func TestReloadQueue_CloseUnblocksProducer(t *testing.T) {
+	q := newReloadQueue(1, OverflowBlock)
+	q.push(request(&source{}, 0))
+
+	pushed := make(chan struct{})
+	go func() {
+		q.push(request(&source{}, 1))
+		close(pushed)
+	}()
+
+	select {
+	case <-pushed:
+		t.Fatal("expected push to block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	q.close()
+
+	select {
+	case <-pushed:
+	case <-time.After(time.Second):
+		t.Fatal("expected close to unblock the producer")
+	}
+	if _, ok := q.pop(); ok {
+		t.Fatal("expected pop to return false once the queue is closed")
+	}
+}
@@ -0,0 +1,87 @@
+package koanfext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ReloadResult reports the outcome of a Reload.
+type ReloadResult struct {
+	// Changed is true if the reload committed a configuration that differs from
+	// the previous one.
+	Changed bool
+
+	// Changes describes the difference between the previous and the reloaded
+	// configuration. It is empty if the reload failed.
+	Changes ChangeSet
+
+	// Errors holds the error of every Source that failed to load, keyed by the
+	// name of the Source. It includes Sources whose Policy allowed the reload
+	// to proceed despite the failure.
+	Errors map[string]error
+}
+
+// Reload synchronously re-reads the Sources with the given names, or all the
+// Sources if no names are given, and reloads the configuration. The reload
+// goes through the same path as a watch triggered reload: the configuration is
+// validated before being committed, listeners are notified on success, and
+// failures are reported through OnError.
+//
+// Reload is useful to force a refresh from an admin endpoint or a signal
+// handler, or for deployments where the Providers aren't Watchable. If ctx is
+// done before all the Sources are read the reload is abandoned and the error
+// of ctx is returned without being reported through OnError.
+func (k *KoanfWrapper) Reload(ctx context.Context, names ...string) (ReloadResult, error) {
+	var refresh map[*source]bool
+	if len(names) > 0 {
+		var err error
+		if refresh, err = k.sourcesNamed(names); err != nil {
+			return ReloadResult{}, err
+		}
+	}
+
+	// A Source removed in the meantime is no longer merged, so it is simply
+	// not read.
+	result, err := k.guarded(func() (reloadResult, error) {
+		return k.load(ctx, refresh)
+	})
+	k.reportRecovered(result.recovered)
+	if errors.Is(err, errClosed) {
+		return ReloadResult{}, err
+	}
+
+	out := ReloadResult{
+		Changed: !result.changes.Empty(),
+		Changes: result.changes,
+		Errors:  result.sourceErrs,
+	}
+	if err != nil {
+		// An abandoned reload is the caller's doing, such as a client of an
+		// admin endpoint disconnecting, rather than a failure of the Sources.
+		if ctxErr := ctx.Err(); ctxErr == nil || err != ctxErr {
+			k.reportError(err)
+		}
+		return out, err
+	}
+
+	k.notify(result)
+	return out, nil
+}
+
+// sourcesNamed returns the Sources with the given names, or an error if one of
+// them doesn't exist.
+func (k *KoanfWrapper) sourcesNamed(names []string) (map[*source]bool, error) {
+	k.sourcesMu.RLock()
+	defer k.sourcesMu.RUnlock()
+
+	sources := make(map[*source]bool, len(names))
+	for _, name := range names {
+		index := k.indexOfLocked(name)
+		if index < 0 {
+			return nil, fmt.Errorf("source %s does not exist", name)
+		}
+		sources[k.sources[index]] = true
+	}
+	return sources, nil
+}
@@ -0,0 +1,118 @@
+package koanfext
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestKoanfWrapper_ReloadFails(t *testing.T) {
+	p := &mutableProvider{conf: map[string]interface{}{"name": "mutable"}}
+	k, err := NewKoanfWrapper(Sources(
+		Source{Name: "base", Provider: staticProvider{"name": "base"}},
+		Source{Name: "mutable", Provider: p}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close(context.Background())
+
+	changed := 0
+	k.OnConfigChanged(func() { changed++ })
+	var reported []error
+	k.OnError(func(err error) { reported = append(reported, err) })
+
+	readErr := errors.New("read failed")
+	p.fail(readErr)
+	result, err := k.Reload(context.Background())
+
+	var reloadErr *ReloadError
+	if !errors.As(err, &reloadErr) {
+		t.Fatalf("expected a *ReloadError, got %v", err)
+	}
+	if reloadErr.Source != "mutable" || reloadErr.Index != 1 || !errors.Is(reloadErr, readErr) {
+		t.Fatalf("expected the error to name the failing source, got %v", reloadErr)
+	}
+	if len(result.Errors) != 1 || !errors.Is(result.Errors["mutable"], readErr) {
+		t.Fatalf("expected the result to hold the error of the failing source, got %v", result.Errors)
+	}
+	if result.Changed {
+		t.Fatal("expected the failed reload not to change the configuration")
+	}
+	if changed != 0 {
+		t.Fatalf("expected listeners not to be notified, got %d", changed)
+	}
+	if len(reported) != 1 || !errors.Is(reported[0], readErr) {
+		t.Fatalf("expected the failure to be reported through OnError, got %v", reported)
+	}
+	if got := k.String("name"); got != "mutable" {
+		t.Fatalf("expected the previous configuration to be kept, got name %q", got)
+	}
+}
+
+func TestKoanfWrapper_ReloadNamed(t *testing.T) {
+	first, second := newFakeProvider(false), newFakeProvider(false)
+	k, err := NewKoanfWrapper(Sources(
+		Source{Name: "first", Provider: first, Mount: "first"},
+		Source{Name: "second", Provider: second, Mount: "second"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close(context.Background())
+
+	changed := 0
+	k.OnConfigChanged(func() { changed++ })
+
+	result, err := k.Reload(context.Background(), "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Changed {
+		t.Fatal("expected the reload to change the configuration")
+	}
+	if changed != 1 {
+		t.Fatalf("expected listeners to be notified once, got %d", changed)
+	}
+	if reads := first.reads.Load(); reads != 1 {
+		t.Fatalf("expected the first source not to be read again, got %d reads", reads)
+	}
+	if reads := second.reads.Load(); reads != 2 {
+		t.Fatalf("expected the second source to be read again, got %d reads", reads)
+	}
+	if got := k.Int("first.reads"); got != 1 {
+		t.Fatalf("expected the first source to be merged from its cached layer, got %d", got)
+	}
+	if got := k.Int("second.reads"); got != 2 {
+		t.Fatalf("expected the second source to be reloaded, got %d", got)
+	}
+
+	if _, err := k.Reload(context.Background(), "missing"); err == nil {
+		t.Fatal("expected reloading an unknown source to fail")
+	}
+	if reads := first.reads.Load() + second.reads.Load(); reads != 3 {
+		t.Fatalf("expected no source to be read when a name is unknown, got %d reads", reads)
+	}
+}
+
+func TestKoanfWrapper_ReloadCancelled(t *testing.T) {
+	p := newFakeProvider(false)
+	k, err := NewKoanfWrapper(Sources(Source{Name: "fake", Provider: p}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close(context.Background())
+
+	var reported []error
+	k.OnError(func(err error) { reported = append(reported, err) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := k.Reload(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the error of ctx, got %v", err)
+	}
+	if len(reported) != 0 {
+		t.Fatalf("expected the abandoned reload not to be reported, got %v", reported)
+	}
+	if reads := p.reads.Load(); reads != 1 {
+		t.Fatalf("expected the source not to be read, got %d reads", reads)
+	}
+}
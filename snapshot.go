@@ -0,0 +1,319 @@
+package koanfext
+
+import (
+	"time"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// Snapshot is an immutable, point-in-time view of the configuration loaded by
+// KoanfWrapper. A Snapshot is never modified after it is published, so it is
+// safe for concurrent use and can be held for the duration of a request to
+// guarantee every read observes the same configuration, even if a reload
+// happens in the meantime.
+//
+// Snapshot exposes the read-only portion of the koanf.Koanf API. Use Koanf to
+// obtain a mutable copy when the full API is required.
+type Snapshot struct {
+	ko        *koanf.Koanf
+	origins   map[string]Origin
+	sensitive map[string][]string
+}
+
+func newSnapshot(ko *koanf.Koanf, origins map[string]Origin, sensitive map[string][]string) *Snapshot {
+	return &Snapshot{
+		ko:        ko,
+		origins:   origins,
+		sensitive: sensitive,
+	}
+}
+
+// Koanf returns a copy of the underlying koanf.Koanf. Changes made to the copy
+// are not reflected in the Snapshot or KoanfWrapper.
+func (s *Snapshot) Koanf() *koanf.Koanf {
+	return s.ko.Copy()
+}
+
+// Get returns the raw, uncast value of a given key path or nil if the path does
+// not exist.
+func (s *Snapshot) Get(path string) interface{} {
+	return s.ko.Get(path)
+}
+
+// Exists returns true if the given key path exists.
+func (s *Snapshot) Exists(path string) bool {
+	return s.ko.Exists(path)
+}
+
+// Keys returns all flattened keys sorted alphabetically.
+func (s *Snapshot) Keys() []string {
+	return s.ko.Keys()
+}
+
+// KeyMap returns a map of flattened keys and the individual parts of each key.
+func (s *Snapshot) KeyMap() koanf.KeyMap {
+	return s.ko.KeyMap()
+}
+
+// MapKeys returns a sorted list of keys in the map addressed by the given path.
+func (s *Snapshot) MapKeys(path string) []string {
+	return s.ko.MapKeys(path)
+}
+
+// All returns a copy of all flattened key paths and their values.
+func (s *Snapshot) All() map[string]interface{} {
+	return s.ko.All()
+}
+
+// Raw returns a copy of the full nested configuration map.
+func (s *Snapshot) Raw() map[string]interface{} {
+	return s.ko.Raw()
+}
+
+// Sprint returns a key -> value representation of the configuration sorted by
+// key.
+func (s *Snapshot) Sprint() string {
+	return s.ko.Sprint()
+}
+
+// Cut returns a new koanf.Koanf holding a copy of the config map at the given
+// path.
+func (s *Snapshot) Cut(path string) *koanf.Koanf {
+	return s.ko.Cut(path)
+}
+
+// Slices returns a list of koanf.Koanf instances built from a slice of maps at
+// the given path.
+func (s *Snapshot) Slices(path string) []*koanf.Koanf {
+	return s.ko.Slices(path)
+}
+
+// Marshal encodes the configuration using the given koanf.Parser.
+func (s *Snapshot) Marshal(p koanf.Parser) ([]byte, error) {
+	return s.ko.Marshal(p)
+}
+
+// Unmarshal decodes the configuration at the given path into o.
+func (s *Snapshot) Unmarshal(path string, o interface{}) error {
+	return s.ko.Unmarshal(path, o)
+}
+
+// UnmarshalWithConf decodes the configuration at the given path into o using
+// the given koanf.UnmarshalConf.
+func (s *Snapshot) UnmarshalWithConf(path string, o interface{}, c koanf.UnmarshalConf) error {
+	return s.ko.UnmarshalWithConf(path, o, c)
+}
+
+// Delim returns the key path delimiter.
+func (s *Snapshot) Delim() string {
+	return s.ko.Delim()
+}
+
+// String returns the string value of a given key path or "" if the path does
+// not exist.
+func (s *Snapshot) String(path string) string {
+	return s.ko.String(path)
+}
+
+// MustString returns the string value of a given key path and panics if the
+// path does not exist.
+func (s *Snapshot) MustString(path string) string {
+	return s.ko.MustString(path)
+}
+
+// Strings returns the []string value of a given key path.
+func (s *Snapshot) Strings(path string) []string {
+	return s.ko.Strings(path)
+}
+
+// MustStrings returns the []string value of a given key path and panics if the
+// path does not exist.
+func (s *Snapshot) MustStrings(path string) []string {
+	return s.ko.MustStrings(path)
+}
+
+// StringMap returns the map[string]string value of a given key path.
+func (s *Snapshot) StringMap(path string) map[string]string {
+	return s.ko.StringMap(path)
+}
+
+// MustStringMap returns the map[string]string value of a given key path and
+// panics if the path does not exist.
+func (s *Snapshot) MustStringMap(path string) map[string]string {
+	return s.ko.MustStringMap(path)
+}
+
+// StringsMap returns the map[string][]string value of a given key path.
+func (s *Snapshot) StringsMap(path string) map[string][]string {
+	return s.ko.StringsMap(path)
+}
+
+// MustStringsMap returns the map[string][]string value of a given key path and
+// panics if the path does not exist.
+func (s *Snapshot) MustStringsMap(path string) map[string][]string {
+	return s.ko.MustStringsMap(path)
+}
+
+// Bytes returns the []byte value of a given key path.
+func (s *Snapshot) Bytes(path string) []byte {
+	return s.ko.Bytes(path)
+}
+
+// MustBytes returns the []byte value of a given key path and panics if the path
+// does not exist.
+func (s *Snapshot) MustBytes(path string) []byte {
+	return s.ko.MustBytes(path)
+}
+
+// Int returns the int value of a given key path or 0 if the path does not
+// exist.
+func (s *Snapshot) Int(path string) int {
+	return s.ko.Int(path)
+}
+
+// MustInt returns the int value of a given key path and panics if the path does
+// not exist.
+func (s *Snapshot) MustInt(path string) int {
+	return s.ko.MustInt(path)
+}
+
+// Ints returns the []int value of a given key path.
+func (s *Snapshot) Ints(path string) []int {
+	return s.ko.Ints(path)
+}
+
+// MustInts returns the []int value of a given key path and panics if the path
+// does not exist.
+func (s *Snapshot) MustInts(path string) []int {
+	return s.ko.MustInts(path)
+}
+
+// IntMap returns the map[string]int value of a given key path.
+func (s *Snapshot) IntMap(path string) map[string]int {
+	return s.ko.IntMap(path)
+}
+
+// MustIntMap returns the map[string]int value of a given key path and panics if
+// the path does not exist.
+func (s *Snapshot) MustIntMap(path string) map[string]int {
+	return s.ko.MustIntMap(path)
+}
+
+// Int64 returns the int64 value of a given key path or 0 if the path does not
+// exist.
+func (s *Snapshot) Int64(path string) int64 {
+	return s.ko.Int64(path)
+}
+
+// MustInt64 returns the int64 value of a given key path and panics if the path
+// does not exist.
+func (s *Snapshot) MustInt64(path string) int64 {
+	return s.ko.MustInt64(path)
+}
+
+// Int64s returns the []int64 value of a given key path.
+func (s *Snapshot) Int64s(path string) []int64 {
+	return s.ko.Int64s(path)
+}
+
+// MustInt64s returns the []int64 value of a given key path and panics if the
+// path does not exist.
+func (s *Snapshot) MustInt64s(path string) []int64 {
+	return s.ko.MustInt64s(path)
+}
+
+// Int64Map returns the map[string]int64 value of a given key path.
+func (s *Snapshot) Int64Map(path string) map[string]int64 {
+	return s.ko.Int64Map(path)
+}
+
+// MustInt64Map returns the map[string]int64 value of a given key path and
+// panics if the path does not exist.
+func (s *Snapshot) MustInt64Map(path string) map[string]int64 {
+	return s.ko.MustInt64Map(path)
+}
+
+// Float64 returns the float64 value of a given key path or 0 if the path does
+// not exist.
+func (s *Snapshot) Float64(path string) float64 {
+	return s.ko.Float64(path)
+}
+
+// MustFloat64 returns the float64 value of a given key path and panics if the
+// path does not exist.
+func (s *Snapshot) MustFloat64(path string) float64 {
+	return s.ko.MustFloat64(path)
+}
+
+// Float64s returns the []float64 value of a given key path.
+func (s *Snapshot) Float64s(path string) []float64 {
+	return s.ko.Float64s(path)
+}
+
+// MustFloat64s returns the []float64 value of a given key path and panics if
+// the path does not exist.
+func (s *Snapshot) MustFloat64s(path string) []float64 {
+	return s.ko.MustFloat64s(path)
+}
+
+// Float64Map returns the map[string]float64 value of a given key path.
+func (s *Snapshot) Float64Map(path string) map[string]float64 {
+	return s.ko.Float64Map(path)
+}
+
+// MustFloat64Map returns the map[string]float64 value of a given key path and
+// panics if the path does not exist.
+func (s *Snapshot) MustFloat64Map(path string) map[string]float64 {
+	return s.ko.MustFloat64Map(path)
+}
+
+// Bool returns the bool value of a given key path or false if the path does not
+// exist.
+func (s *Snapshot) Bool(path string) bool {
+	return s.ko.Bool(path)
+}
+
+// Bools returns the []bool value of a given key path.
+func (s *Snapshot) Bools(path string) []bool {
+	return s.ko.Bools(path)
+}
+
+// MustBools returns the []bool value of a given key path and panics if the path
+// does not exist.
+func (s *Snapshot) MustBools(path string) []bool {
+	return s.ko.MustBools(path)
+}
+
+// BoolMap returns the map[string]bool value of a given key path.
+func (s *Snapshot) BoolMap(path string) map[string]bool {
+	return s.ko.BoolMap(path)
+}
+
+// MustBoolMap returns the map[string]bool value of a given key path and panics
+// if the path does not exist.
+func (s *Snapshot) MustBoolMap(path string) map[string]bool {
+	return s.ko.MustBoolMap(path)
+}
+
+// Duration returns the time.Duration value of a given key path.
+func (s *Snapshot) Duration(path string) time.Duration {
+	return s.ko.Duration(path)
+}
+
+// MustDuration returns the time.Duration value of a given key path and panics
+// if the path does not exist.
+func (s *Snapshot) MustDuration(path string) time.Duration {
+	return s.ko.MustDuration(path)
+}
+
+// Time parses the value of a given key path as time.Time using the given
+// layout.
+func (s *Snapshot) Time(path, layout string) time.Time {
+	return s.ko.Time(path, layout)
+}
+
+// MustTime parses the value of a given key path as time.Time and panics if the
+// path does not exist.
+func (s *Snapshot) MustTime(path, layout string) time.Time {
+	return s.ko.MustTime(path, layout)
+}
@@ -0,0 +1,363 @@
+package koanfext
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/knadh/koanf/maps"
+	"github.com/knadh/koanf/v2"
+)
+
+// Policy determines how KoanfWrapper reacts when a Source fails to load.
+type Policy uint8
+
+const (
+	// Required fails the load when the Source can't be loaded. This is the
+	// default Policy.
+	Required Policy = iota
+
+	// Optional skips the Source when it doesn't exist, such as a local
+	// override file or a Redis key that isn't set, as indicated by an error
+	// matching ErrNotExist or fs.ErrNotExist. The configuration is loaded as if
+	// the Source wasn't configured.
+	//
+	// A Source whose store is unreachable, as indicated by an error matching
+	// ErrUnavailable or context.DeadlineExceeded or implementing net.Error, is
+	// only skipped if it has no configuration to keep, such as on startup.
+	// Otherwise, the configuration previously loaded from it is kept like
+	// UseLastKnownGood, so a transient outage doesn't drop its overrides.
+	// Other errors, such as a file that fails to parse, fail the load like
+	// Required.
+	Optional
+
+	// UseLastKnownGood reuses the configuration previously loaded from the
+	// Source when it can't be loaded. If the Source has never been loaded
+	// successfully, such as on startup, it behaves like Required.
+	//
+	// Failures tolerated by Optional and UseLastKnownGood are reported through
+	// OnError as a *ReloadError with Tolerated set. If such a Source also fails
+	// to be watched when KoanfWrapper is initialized, the *WatchError is
+	// reported through OnError as well instead of failing NewKoanfWrapper.
+	UseLastKnownGood
+)
+
+func (p Policy) String() string {
+	switch p {
+	case Required:
+		return "required"
+	case Optional:
+		return "optional"
+	case UseLastKnownGood:
+		return "use-last-known-good"
+	default:
+		return fmt.Sprintf("Policy(%d)", p)
+	}
+}
+
+// Source represents the source of a configuration. The source contains the
+// Provider to load read the configuration, and the Parser to decode it.
+//
+// Name identifies the Source in errors and notifications. When Name is empty
+// a name is derived from the type of the Provider and the position of the
+// Source.
+//
+// Policy determines what happens when the Source fails to load, by default a
+// Source is Required.
+//
+// Mount nests the configuration supplied by the Source under the given key
+// path before it is merged, e.g. a Mount of "features" turns {"beta": true}
+// into {"features": {"beta": true}}. This allows Sources with flat or
+// unrelated structures, such as feature flags stored in Redis or the data of
+// a ConfigMap, to be merged without colliding with other Sources. Sensitive
+// patterns are matched against the mounted key path.
+//
+// Merge determines how the configuration of the Source is merged with the
+// Sources before it, by default maps are merged recursively while other values
+// are replaced. MergeKey is the field used to identify elements that are maps
+// when merging slices with MergeUnion.
+//
+// Sensitive marks keys supplied by the Source as sensitive so their values are
+// masked by Dump. The patterns are matched like DumpOptions.SensitiveKeys,
+// including against the keys of maps nested in slices. For instance "*" marks
+// every key supplied by a Source holding secrets.
+type Source struct {
+	Name      string
+	Provider  koanf.Provider
+	Parser    koanf.Parser
+	Policy    Policy
+	Mount     string
+	Merge     MergeStrategy
+	MergeKey  string
+	Sensitive []string
+}
+
+// source is a Source along with the state KoanfWrapper tracks for it.
+type source struct {
+	Source
+	name string
+
+	// layer is the configuration loaded from the Source as of the current
+	// Snapshot. It is nil if the Source is Optional and failed to load.
+	layer map[string]interface{}
+
+	closeOnce sync.Once
+	closeErr  error
+
+	// statusMu guards the fields reported by Status, which are updated by watch
+	// callbacks as well as loads.
+	statusMu   sync.Mutex
+	watching   bool
+	lastLoaded time.Time
+	lastErr    error
+	lastErrAt  time.Time
+	hash       string
+	revision   string
+}
+
+func newSource(s Source, index int) *source {
+	name := s.Name
+	if name == "" {
+		name = fmt.Sprintf("%T[%d]", s.Provider, index)
+	}
+	return &source{
+		Source: s,
+		name:   name,
+	}
+}
+
+// read reads and parses the configuration from the Provider and nests it under
+// the Mount path. If the Source has no Parser the Provider is expected to
+// return the parsed configuration from Read, mirroring koanf.Koanf.Load. A
+// panic in the Provider or Parser is recovered and returned as a *PanicError.
+func (s *source) read(delim string) (layer map[string]interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			layer, err = nil, newPanicError(r)
+		}
+	}()
+
+	if s.Parser == nil {
+		layer, err = s.Provider.Read()
+	} else {
+		var data []byte
+		data, err = s.Provider.ReadBytes()
+		if err != nil {
+			return nil, err
+		}
+		layer, err = s.Parser.Unmarshal(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return mount(layer, s.Mount, delim), nil
+}
+
+// mount nests layer under the key path. An empty path returns layer as is.
+func mount(layer map[string]interface{}, path, delim string) map[string]interface{} {
+	if path == "" || layer == nil {
+		return layer
+	}
+
+	keys := strings.Split(path, delim)
+	for i := len(keys) - 1; i >= 0; i-- {
+		layer = map[string]interface{}{keys[i]: layer}
+	}
+	return layer
+}
+
+// layerProvider is a koanf.Provider serving a copy of a previously loaded
+// layer, so merging it can never modify the layer itself.
+type layerProvider map[string]interface{}
+
+func (l layerProvider) ReadBytes() ([]byte, error) {
+	return nil, fmt.Errorf("%T does not support ReadBytes()", l)
+}
+
+func (l layerProvider) Read() (map[string]interface{}, error) {
+	return maps.Copy(l), nil
+}
+
+// AddSource adds a Source named name at the given position among the Sources,
+// shifting the Sources at and after position back. A position that is
+// negative or beyond the last Source appends the Source.
+//
+// If the Provider is Watchable it is watched, then the new Source is loaded and
+// the configuration reloaded through the same path as a watch triggered
+// reload, notifying listeners on success. If the Source fails to load, the
+// resulting configuration is rejected, or KoanfWrapper is closed, the Source
+// is not added, its Provider is closed if it implements io.Closer, and an
+// error is returned. The error is
+// a *WatchError if the watch failed to start.
+func (k *KoanfWrapper) AddSource(name string, s Source, position int) error {
+	if name == "" {
+		return fmt.Errorf("source name cannot be empty")
+	}
+	s.Name = name
+	src := newSource(s, position)
+
+	k.sourcesMu.RLock()
+	exists := k.indexOfLocked(name) >= 0
+	if position < 0 || position > len(k.sources) {
+		position = len(k.sources)
+	}
+	k.sourcesMu.RUnlock()
+	if exists {
+		return fmt.Errorf("source %s already exists", name)
+	}
+
+	result, err := k.guarded(func() (reloadResult, error) {
+		// The watch is started before the Source is added, events received in
+		// the meantime are ignored since the Source is read when it is added
+		// anyway.
+		var recovered []error
+		if err := k.watch(src, &recovered); err != nil {
+			return reloadResult{recovered: recovered}, &WatchError{Source: name, Index: position, Err: err}
+		}
+		result, err := k.insertSource(src, position)
+		result.recovered = append(recovered, result.recovered...)
+		return result, err
+	})
+	k.reportRecovered(result.recovered)
+	if err != nil {
+		src.close()
+		return err
+	}
+
+	k.notify(result)
+	return nil
+}
+
+// insertSource inserts src at the given position among the Sources and loads
+// it. If the load fails the Sources are left unchanged. If KoanfWrapper has
+// been closed in the meantime, src is closed and errClosed is returned.
+func (k *KoanfWrapper) insertSource(src *source, position int) (reloadResult, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.indexOfLocked(src.name) >= 0 {
+		return reloadResult{}, fmt.Errorf("source %s already exists", src.name)
+	}
+
+	previous := k.sources
+	if position < 0 || position > len(previous) {
+		position = len(previous)
+	}
+	sources := make([]*source, 0, len(previous)+1)
+	sources = append(sources, previous[:position]...)
+	sources = append(sources, src)
+	sources = append(sources, previous[position:]...)
+
+	// Close closes the Providers of the Sources it finds once closed is set.
+	// Checking closed while adding the Source ensures it is either closed here
+	// or found by Close.
+	k.closeMu.RLock()
+	if k.closed {
+		k.closeMu.RUnlock()
+		src.close()
+		return reloadResult{}, errClosed
+	}
+	k.setSources(sources)
+	k.closeMu.RUnlock()
+
+	result, err := k.loadLocked(context.Background(), map[*source]bool{src: true})
+	if err != nil {
+		k.setSources(previous)
+		return result, err
+	}
+	return result, nil
+}
+
+// RemoveSource removes the Source with the given name, stops watching it and
+// closes its Provider if it implements io.Closer. The configuration is reloaded
+// without the Source, notifying listeners on success. If the resulting
+// configuration is rejected the Source is kept and an error is returned.
+func (k *KoanfWrapper) RemoveSource(name string) error {
+	var src *source
+	result, err := k.guarded(func() (result reloadResult, err error) {
+		src, result, err = k.removeSource(name)
+		return result, err
+	})
+	k.reportRecovered(result.recovered)
+	if err != nil {
+		return err
+	}
+
+	k.notify(result)
+	return src.close()
+}
+
+// removeSource removes the named Source and reloads the configuration without
+// it. If the load fails the Sources are left unchanged.
+func (k *KoanfWrapper) removeSource(name string) (*source, reloadResult, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	index := k.indexOfLocked(name)
+	if index < 0 {
+		return nil, reloadResult{}, fmt.Errorf("source %s does not exist", name)
+	}
+
+	previous := k.sources
+	src := previous[index]
+	sources := make([]*source, 0, len(previous)-1)
+	sources = append(sources, previous[:index]...)
+	sources = append(sources, previous[index+1:]...)
+	k.setSources(sources)
+
+	// None of the remaining Sources have changed so their cached layers are
+	// merged without reading them again.
+	result, err := k.loadLocked(context.Background(), map[*source]bool{})
+	if err != nil {
+		k.setSources(previous)
+		return nil, result, err
+	}
+	return src, result, nil
+}
+
+// close closes the Provider if it implements io.Closer. The Provider is only
+// closed once, since a Source being added or removed concurrently with Close
+// may be closed by both.
+func (s *source) close() error {
+	s.closeOnce.Do(func() {
+		if closer, ok := s.Provider.(io.Closer); ok {
+			s.closeErr = closer.Close()
+		}
+	})
+	return s.closeErr
+}
+
+// setSources replaces the Sources. mu must be held.
+func (k *KoanfWrapper) setSources(sources []*source) {
+	k.sourcesMu.Lock()
+	defer k.sourcesMu.Unlock()
+	k.sources = sources
+}
+
+// indexOf returns the position of src among the Sources, or -1 if it has been
+// removed.
+func (k *KoanfWrapper) indexOf(src *source) int {
+	k.sourcesMu.RLock()
+	defer k.sourcesMu.RUnlock()
+	for i, s := range k.sources {
+		if s == src {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexOfLocked returns the position of the Source with the given name, or -1
+// if there is none. mu or sourcesMu must be held.
+func (k *KoanfWrapper) indexOfLocked(name string) int {
+	for i, s := range k.sources {
+		if s.name == name {
+			return i
+		}
+	}
+	return -1
+}
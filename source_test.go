@@ -0,0 +1,212 @@
+package koanfext
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/knadh/koanf/v2"
+)
+
+// closeTracker is a koanf.Provider recording whether it was closed.
+type closeTracker struct {
+	koanf.Provider
+	closed atomic.Int32
+}
+
+func (p *closeTracker) Close() error {
+	p.closed.Add(1)
+	return nil
+}
+
+// failingProvider is a koanf.Provider that always fails to be read.
+type failingProvider struct{}
+
+func (failingProvider) ReadBytes() ([]byte, error) {
+	return nil, errors.New("read failed")
+}
+
+func (failingProvider) Read() (map[string]interface{}, error) {
+	return nil, errors.New("read failed")
+}
+
+func sourceNames(k *KoanfWrapper) []string {
+	var names []string
+	for _, s := range k.Status() {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestKoanfWrapper_AddSource(t *testing.T) {
+	k, err := NewKoanfWrapper(Sources(
+		Source{Name: "first", Provider: staticProvider{"name": "first", "first": true}},
+		Source{Name: "last", Provider: staticProvider{"name": "last", "last": true}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close(context.Background())
+
+	changed := 0
+	k.OnConfigChanged(func() { changed++ })
+
+	p := &closeTracker{Provider: staticProvider{"name": "middle", "middle": true}}
+	if err := k.AddSource("middle", Source{Provider: p}, 1); err != nil {
+		t.Fatalf("expected the source to be added, got %v", err)
+	}
+
+	if got, want := sourceNames(k), []string{"first", "middle", "last"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected sources %v, got %v", want, got)
+	}
+	if got := k.String("name"); got != "last" {
+		t.Fatalf("expected name to be last, got %q", got)
+	}
+	if !k.Bool("middle") {
+		t.Fatal("expected the configuration of the added source to be merged")
+	}
+	if changed != 1 {
+		t.Fatalf("expected listeners to be notified once, got %d", changed)
+	}
+	if got, _ := k.Origin("middle"); got != (Origin{Source: "middle", Index: 1}) {
+		t.Fatalf("expected middle to originate from the added source, got %v", got)
+	}
+	if got, _ := k.Origin("last"); got != (Origin{Source: "last", Index: 2}) {
+		t.Fatalf("expected the last source to be shifted back, got %v", got)
+	}
+	if p.closed.Load() != 0 {
+		t.Fatal("expected the provider of the added source to be left open")
+	}
+}
+
+func TestKoanfWrapper_AddSourceDuplicate(t *testing.T) {
+	k, err := NewKoanfWrapper(Sources(Source{Name: "base", Provider: staticProvider{"name": "base"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close(context.Background())
+
+	if err := k.AddSource("base", Source{Provider: staticProvider{"name": "duplicate"}}, -1); err == nil {
+		t.Fatal("expected a source with a duplicate name to be rejected")
+	}
+	if got := k.String("name"); got != "base" {
+		t.Fatalf("expected name to be base, got %q", got)
+	}
+	if got, want := sourceNames(k), []string{"base"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected sources %v, got %v", want, got)
+	}
+}
+
+func TestKoanfWrapper_AddSourceRollback(t *testing.T) {
+	tests := map[string]struct {
+		provider   koanf.Provider
+		validators []Validator
+	}{
+		"load fails": {
+			provider: failingProvider{},
+		},
+		"validator fails": {
+			provider: staticProvider{"name": "invalid"},
+			validators: []Validator{func(candidate *Snapshot) error {
+				if candidate.String("name") == "invalid" {
+					return errors.New("invalid name")
+				}
+				return nil
+			}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			k, err := NewKoanfWrapper(
+				Sources(Source{Name: "base", Provider: staticProvider{"name": "base"}}),
+				Validators(tt.validators...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer k.Close(context.Background())
+
+			changed := 0
+			k.OnConfigChanged(func() { changed++ })
+
+			p := &closeTracker{Provider: tt.provider}
+			err = k.AddSource("added", Source{Provider: p}, -1)
+			var reloadErr *ReloadError
+			if !errors.As(err, &reloadErr) {
+				t.Fatalf("expected a *ReloadError, got %v", err)
+			}
+
+			if got, want := sourceNames(k), []string{"base"}; !reflect.DeepEqual(got, want) {
+				t.Fatalf("expected sources %v, got %v", want, got)
+			}
+			if got := k.String("name"); got != "base" {
+				t.Fatalf("expected name to be base, got %q", got)
+			}
+			if changed != 0 {
+				t.Fatalf("expected listeners not to be notified, got %d", changed)
+			}
+			if p.closed.Load() != 1 {
+				t.Fatalf("expected the provider to be closed once, got %d", p.closed.Load())
+			}
+		})
+	}
+}
+
+func TestKoanfWrapper_AddSourceAfterClose(t *testing.T) {
+	k, err := NewKoanfWrapper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &closeTracker{Provider: staticProvider{"name": "added"}}
+	if err := k.AddSource("added", Source{Provider: p}, -1); !errors.Is(err, errClosed) {
+		t.Fatalf("expected the source to be rejected once closed, got %v", err)
+	}
+	if p.closed.Load() != 1 {
+		t.Fatalf("expected the provider to be closed once, got %d", p.closed.Load())
+	}
+}
+
+func TestKoanfWrapper_RemoveSource(t *testing.T) {
+	p := newFakeProvider(false)
+	k, err := NewKoanfWrapper(Sources(
+		Source{Name: "base", Provider: staticProvider{"name": "base"}},
+		Source{Name: "fake", Provider: p}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close(context.Background())
+
+	if err := k.RemoveSource("missing"); err == nil {
+		t.Fatal("expected removing an unknown source to fail")
+	}
+	if err := k.RemoveSource("fake"); err != nil {
+		t.Fatalf("expected the source to be removed, got %v", err)
+	}
+
+	if got, want := sourceNames(k), []string{"base"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected sources %v, got %v", want, got)
+	}
+	if k.Exists("reads") {
+		t.Fatal("expected the configuration of the removed source to be dropped")
+	}
+
+	// Events reported by the Provider of the removed Source are ignored
+	changed := make(chan struct{}, 1)
+	k.OnConfigChanged(func() { changed <- struct{}{} })
+	p.emit()
+
+	select {
+	case <-changed:
+		t.Fatal("expected events from the removed source to be ignored")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if reads := p.reads.Load(); reads != 1 {
+		t.Fatalf("expected the removed source not to be read again, got %d reads", reads)
+	}
+}
@@ -0,0 +1,121 @@
+package koanfext
+
+import (
+	"encoding/hex"
+	"time"
+)
+
+// SourceStatus reports the health of a Source, such as whether its Provider is
+// still being watched and when it was last loaded successfully.
+type SourceStatus struct {
+	Name   string
+	Index  int
+	Policy Policy
+
+	// Watchable reports whether the Provider implements Watchable.
+	Watchable bool
+
+	// Watching reports whether the Provider is currently being watched. It is
+	// false once the Provider reports that its watch has Stopped, such as a
+	// Redis subscription or MongoDB change stream that was terminated.
+	Watching bool
+
+	// LastLoaded is the last time the Source was read successfully, or zero if
+	// it never was. A read may succeed while the configuration as a whole is
+	// rejected, for instance by a Validator.
+	LastLoaded time.Time
+
+	// LastError is the last error reported for the Source, either by reading it
+	// or by its Provider while watching. LastErrorAt is when it occurred. Both
+	// are kept after the Source recovers, compare LastErrorAt with LastLoaded
+	// to tell whether the Source is currently failing.
+	LastError   error
+	LastErrorAt time.Time
+
+	// Hash is the hex encoded SHA-256 hash of the configuration last read from
+	// the Source. It is empty if the Source has never been read successfully.
+	Hash string
+
+	// Revision is the Revision of the last Event reported by the Provider, if
+	// it supplies one.
+	Revision string
+}
+
+// Status returns the status of every Source in the order they are merged.
+//
+// Status doesn't wait for an in-progress reload, so it is suitable for health
+// and readiness checks.
+func (k *KoanfWrapper) Status() []SourceStatus {
+	k.sourcesMu.RLock()
+	sources := k.sources
+	k.sourcesMu.RUnlock()
+
+	statuses := make([]SourceStatus, 0, len(sources))
+	for i, src := range sources {
+		statuses = append(statuses, src.status(i))
+	}
+	return statuses
+}
+
+// status returns the status of the Source at the given position.
+func (s *source) status(index int) SourceStatus {
+	_, watchable := s.Provider.(Watchable)
+
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	return SourceStatus{
+		Name:        s.name,
+		Index:       index,
+		Policy:      s.Policy,
+		Watchable:   watchable,
+		Watching:    s.watching,
+		LastLoaded:  s.lastLoaded,
+		LastError:   s.lastErr,
+		LastErrorAt: s.lastErrAt,
+		Hash:        s.hash,
+		Revision:    s.revision,
+	}
+}
+
+// recordLoad records a successful read of the Source.
+func (s *source) recordLoad(layer map[string]interface{}) {
+	hash, err := hashMap(layer)
+
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	s.lastLoaded = time.Now()
+	if err == nil {
+		s.hash = hex.EncodeToString(hash[:])
+	} else {
+		// The configuration can't always be encoded as JSON, in which case the
+		// hash is unknown rather than stale.
+		s.hash = ""
+	}
+}
+
+// recordError records an error reading or watching the Source.
+func (s *source) recordError(err error) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	s.lastErr = err
+	s.lastErrAt = time.Now()
+}
+
+// recordEvent records an Event reported by the Provider of the Source.
+func (s *source) recordEvent(event Event) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	if event.Kind == EventStopped {
+		s.watching = false
+	}
+	if event.Revision != "" {
+		s.revision = event.Revision
+	}
+}
+
+// setWatching records whether the Provider of the Source is being watched.
+func (s *source) setWatching(watching bool) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	s.watching = watching
+}
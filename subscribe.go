@@ -0,0 +1,50 @@
+package koanfext
+
+import (
+	"strings"
+)
+
+// Subscribe registers a ChangeListener that is only invoked when a key equal
+// to or nested under prefix is added, removed or modified. The ChangeSet passed
+// to the listener only contains the changes under prefix. An empty prefix
+// subscribes to all changes, like OnChange.
+//
+// Subscribe returns a function that removes the subscription. It is safe to
+// call more than once.
+func (k *KoanfWrapper) Subscribe(prefix string, fn ChangeListener) (unsubscribe func()) {
+	if fn == nil {
+		panic("fn cannot be nil")
+	}
+
+	return k.onChange.add(func(changes ChangeSet) {
+		scoped := changes.filter(prefix, k.conf.Delim)
+		if !scoped.Empty() {
+			fn(scoped)
+		}
+	})
+}
+
+// filter returns a ChangeSet containing only the changes for keys equal to or
+// nested under prefix.
+func (c ChangeSet) filter(prefix, delim string) ChangeSet {
+	if prefix == "" {
+		return c
+	}
+
+	match := func(changes []Change) []Change {
+		var out []Change
+		for _, change := range changes {
+			if change.Key == prefix || strings.HasPrefix(change.Key, prefix+delim) {
+				out = append(out, change)
+			}
+		}
+		return out
+	}
+
+	return ChangeSet{
+		Added:    match(c.Added),
+		Removed:  match(c.Removed),
+		Modified: match(c.Modified),
+		Events:   c.Events,
+	}
+}
@@ -0,0 +1,67 @@
+package koanfext
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestKoanfWrapper_Subscribe(t *testing.T) {
+	p := &mutableProvider{conf: map[string]interface{}{
+		"db":  map[string]interface{}{"host": "localhost", "port": 5432},
+		"dbx": map[string]interface{}{"host": "localhost"},
+	}}
+	k, err := NewKoanfWrapper(Sources(Source{Name: "mutable", Provider: p}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close(context.Background())
+
+	var notified []ChangeSet
+	unsubscribe := k.Subscribe("db", func(changes ChangeSet) {
+		notified = append(notified, changes)
+	})
+	reload := func(conf map[string]interface{}) {
+		t.Helper()
+		p.set(conf)
+		if _, err := k.Reload(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A key sharing the prefix without being nested under it doesn't match
+	reload(map[string]interface{}{
+		"db":  map[string]interface{}{"host": "localhost", "port": 5432},
+		"dbx": map[string]interface{}{"host": "dbx"},
+	})
+	if len(notified) != 0 {
+		t.Fatalf("expected changes under dbx not to be notified, got %v", notified)
+	}
+
+	// Only the changes under the prefix are passed to the listener
+	reload(map[string]interface{}{
+		"db":  map[string]interface{}{"host": "db", "user": "admin"},
+		"dbx": map[string]interface{}{"host": "localhost"},
+	})
+	if len(notified) != 1 {
+		t.Fatalf("expected a single notification, got %d", len(notified))
+	}
+	want := ChangeSet{
+		Added:    []Change{{Key: "db.user", New: "admin"}},
+		Removed:  []Change{{Key: "db.port", Old: 5432}},
+		Modified: []Change{{Key: "db.host", Old: "localhost", New: "db"}},
+	}
+	if got := notified[0]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+
+	// Unsubscribing is safe to repeat and stops notifications
+	unsubscribe()
+	unsubscribe()
+	reload(map[string]interface{}{
+		"db": map[string]interface{}{"host": "changed"},
+	})
+	if len(notified) != 1 {
+		t.Fatalf("expected no notification once unsubscribed, got %d", len(notified))
+	}
+}